@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+// The checkpoint file has the arguments that change the points in the first line, see checkpointArguments, followed
+// by the checkpoint of the picture.
+
+// checkpointArguments returns the arguments that change the points of the picture. The checkpoint has the points and
+// not the colors, so the color can change to resume it as long as the same distance and interior are calculated.
+func checkpointArguments(pic *mandelbrot.Picture) string {
+	arguments := append(resultArguments("color", "distance", "interior"),
+		fmt.Sprintf("-distance=%t", pic.Distance), fmt.Sprintf("-interior=%t", pic.Interior))
+	return strings.Join(arguments, " ")
+}
+
+// loadCheckpoint returns the picture saved in the checkpoint file, or nil if the file does not exist. The checkpoint
+// must have been written with the same arguments.
+func loadCheckpoint(path string, arguments string) (*mandelbrot.Picture, error) {
+	checkpointFile, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer checkpointFile.Close()
+
+	reader := bufio.NewReader(checkpointFile)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if line[:len(line)-1] != arguments {
+		return nil, fmt.Errorf("it was calculated with the arguments %s", line[:len(line)-1])
+	}
+	return mandelbrot.LoadCheckpoint(reader)
+}
+
+// saveCheckpoint writes the checkpoint of the picture, or removes it if the picture is complete. The checkpoint is
+// written to a temporary file that replaces the previous one, so it is not lost if the process dies while writing.
+func saveCheckpoint(path string, arguments string, pic *mandelbrot.Picture) error {
+	if len(pic.Missing()) == 0 {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	temporaryPath := path + ".tmp"
+	checkpointFile, err := os.Create(temporaryPath)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(checkpointFile, arguments)
+	if err == nil {
+		err = pic.SaveCheckpoint(checkpointFile)
+	}
+	if closeErr := checkpointFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temporaryPath)
+		return err
+	}
+	return os.Rename(temporaryPath, path)
+}
+
+// saveCheckpoints writes the checkpoint of the picture every interval until the returned function is called.
+func saveCheckpoints(path string, arguments string, interval time.Duration, pic *mandelbrot.Picture) (stop func()) {
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := saveCheckpoint(path, arguments, pic); err != nil {
+					log.Printf("checkpoint cannot be written, cause: %s", err)
+					continue
+				}
+				log.Printf("Checkpoint written, %d areas missing", len(pic.Missing()))
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// paintComplete paints the complete areas of the picture in a new image.
+func paintComplete(pic *mandelbrot.Picture, colorize colorizer) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, pic.HorizontalResolution(), pic.VerticalResolution()))
+	for i := 0; i < pic.HorizontalImageChunks*pic.VerticalImageChunks; i++ {
+		if pic.Complete(i) {
+			offsetX, offsetY := pic.GetImageOffsetFor(i)
+			paintAreaInImage(img, pic.GetArea(i), offsetX, offsetY, colorize)
+		}
+	}
+	return img
+}
@@ -8,129 +8,476 @@ import (
 	"image/jpeg"
 	"image/png"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/trace"
+	"strings"
 	"time"
 
 	"github.com/metalblueberry/mandelbrot/mandelbrot"
 )
 
 func main() {
-	top := flag.Float64("top", 1.5, "Top mandelbrot position")
-	left := flag.Float64("left", -2.1, "Left mandelbrot position")
-	areaSize := flag.Float64("areaSize", 3, "From the TopLeft, the size of the complex area")
+	if len(os.Args) > 1 && os.Args[1] == "recolor" {
+		if err := recolor(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	top := flag.String("top", "1.5", "Top mandelbrot position")
+	left := flag.String("left", "-2.1", "Left mandelbrot position")
+	centerRe := flag.String("center-re", "", "Real part of the center of the view, it replaces top and left")
+	centerIm := flag.String("center-im", "", "Imaginary part of the center of the view, it replaces top and left")
+	zoom := flag.Float64("zoom", 0, "Magnification around the center of the view, a zoom of 1 is 3 units wide. It replaces areaSize")
+	rotate := flag.Float64("rotate", 0, "Degrees to turn the view counterclockwise around its center")
+	areaSize := flag.Float64("areaSize", 3, "From the TopLeft, the width of the complex area, the height keeps the pixels square")
 
 	imageSize := flag.Int("imageSize", 1920, "Size of the squared image generated in pixels")
-	divisions := flag.Int("divisions", 50, "Number of divisions to split the work over multiple routines")
+	width := flag.Int("width", 0, "Width of the image in pixels, imageSize is used if it is not set")
+	height := flag.Int("height", 0, "Height of the image in pixels, imageSize is used if it is not set")
+	divisions := flag.Int("divisions", 50, "Number of divisions in each direction to split the work over multiple routines")
 	maxIterations := flag.Int("maxIterations", 100, "Maximum number of iterations per point")
+	bailout := flag.Float64("bailout", mandelbrot.DefaultBailout, "Escape radius, larger values give better smooth coloring")
+	colorMode := flag.String("color", "bands", "Coloring mode, it can be bands, smooth, distance or period")
+	formulaName := flag.String("formula", "mandelbrot", "Formula to iterate, it can be mandelbrot, multibrotN (where N is the power), burningship, tricorn, celtic or an expression like \"z^3 - z + c\"")
+	julia := flag.Bool("julia", false, "Draw the Julia set of the point cr + ci*i instead of the Mandelbrot set")
+	cr := flag.Float64("cr", 0, "Real part of the Julia set parameter")
+	ci := flag.Float64("ci", 0, "Imaginary part of the Julia set parameter")
+	perturbation := flag.Bool("perturbation", true, "Use the perturbation method for deep zooms instead of calculating every point with full precision")
+	samples := flag.Int("samples", 1, "Number of samples per pixel, they are averaged to reduce aliasing")
+	sampling := flag.String("sampling", "grid", "Position of the samples inside each pixel, it can be grid, jittered or random")
+	adaptive := flag.Int("adaptive", 0, "Supersample only the pixels that differ from their neighbors, doubling the samples grid up to this many times. It replaces samples")
+	adaptiveThreshold := flag.Float64("adaptiveThreshold", 1, "Difference of iterations between neighbors that triggers the adaptive supersampling")
+	strategyName := flag.String("strategy", "bruteforce", "How the points of each area are visited, it can be bruteforce, subdivision or boundary")
+	orderName := flag.String("order", "rowmajor", "Order in which the areas are calculated, it can be rowmajor, centerout, spiral, hilbert or random")
+	schedulerName := flag.String("scheduler", "queue", "How the areas are shared between the workers, it can be queue or stealing")
+	symmetry := flag.Bool("symmetry", true, "Copy the areas below the real axis from the ones above it when the set is symmetric")
+	interior := flag.Bool("interior", true, "Stop the iteration of points that are proven to be inside the set")
+	distance := flag.Bool("distance", false, "Calculate the distance estimation of every point, it is slower and only needed to recolor a raw file with distance coloring")
+	precision := flag.Uint("precision", 0, "Bits of precision used for deep zooms, by default it is chosen automatically when the pixels are too small for float64")
+
+	progressive := flag.Int("progressive", 0, "Calculate first one pixel of every block of this size and refine it in passes, the image is written after each pass")
+
+	checkpoint := flag.String("checkpoint", "", "File where the state of the calculation is saved periodically, the calculation is resumed from it if it exists")
+	checkpointInterval := flag.Duration("checkpointInterval", time.Minute, "Time between the checkpoints")
 
 	workers := flag.Int("workers", runtime.NumCPU(), "Maximum number of iterations per point")
 	out := flag.String("out", "mandelbrot.jpg", "output file, it can be png or jpg")
+	raw := flag.String("raw", "", "File where the iterations, smooth iterations and distance of every pixel are written, the image can be colored again from it with the recolor command. The distance is only written with -distance or distance coloring")
+	rawCompress := flag.Bool("rawCompress", false, "Compress the raw file with gzip")
 	timeout := flag.Int64("timeout", 20, "Maximum number of seconds to compute, if reached. the program will exit")
 
 	flag.Parse()
 
 	log.Printf("Start")
 
-	pic := mandelbrot.NewPicture(complex(*left, *top), *areaSize, *imageSize, *divisions, *maxIterations)
+	colorize, ok := colorizers[*colorMode]
+	if !ok {
+		log.Fatalf("unknown color mode %s", *colorMode)
+	}
+
+	samplingPattern, ok := mandelbrot.SamplingPatternByName(*sampling)
+	if !ok {
+		log.Fatalf("unknown sampling pattern %s", *sampling)
+	}
+
+	strategy, ok := mandelbrot.StrategyByName(*strategyName)
+	if !ok {
+		log.Fatalf("unknown strategy %s", *strategyName)
+	}
+
+	order, ok := mandelbrot.TileOrderByName(*orderName)
+	if !ok {
+		log.Fatalf("unknown order %s", *orderName)
+	}
+
+	scheduler, ok := mandelbrot.SchedulerByName(*schedulerName)
+	if !ok {
+		log.Fatalf("unknown scheduler %s", *schedulerName)
+	}
+
+	formula, err := mandelbrot.ParseFormula(*formulaName)
+	if parseError, ok := err.(*mandelbrot.ParseError); ok {
+		log.Fatalf("%s\n\t%s\n\t%s^", parseError, parseError.Expression, strings.Repeat(" ", parseError.Position))
+	}
+	if err != nil {
+		log.Fatalf("invalid formula, cause: %s", err)
+	}
+
+	if *width == 0 {
+		*width = *imageSize
+	}
+	if *height == 0 {
+		*height = *imageSize
+	}
+
+	var pic *mandelbrot.Picture
+	var view mandelbrot.View
+	useView := *centerRe != "" || *centerIm != "" || *zoom != 0
+	if useView {
+		center, err := parseCenter(*centerRe, *centerIm, *left, *top, *areaSize, *width, *height, 53)
+		if err != nil {
+			log.Fatalf("invalid center position, cause: %s", err)
+		}
+		view = mandelbrot.View{
+			Center:   center.Complex128(),
+			Zoom:     *zoom,
+			Aspect:   float64(*width) / float64(*height),
+			Rotation: *rotate * math.Pi / 180,
+		}
+		pic, err = view.Picture(*width, *divisions, *maxIterations)
+		if err != nil {
+			log.Fatalf("invalid picture, cause: %s", err)
+		}
+		*areaSize = view.Width()
+	} else {
+		topLeft, err := mandelbrot.ParseBigComplex(*left, *top, 53)
+		if err != nil {
+			log.Fatalf("invalid top left position, cause: %s", err)
+		}
+		pic, err = mandelbrot.NewRectangularPicture(topLeft.Complex128(), *areaSize, *width, *height, *divisions, *divisions, *maxIterations)
+		if err != nil {
+			log.Fatalf("invalid picture, cause: %s", err)
+		}
+		pic.Rotation = *rotate * math.Pi / 180
+	}
+	pic.Bailout = *bailout
+	pic.Julia = *julia
+	pic.C = complex(*cr, *ci)
+	pic.Formula = formula
+	_, differentiable := formula.(mandelbrot.Differentiable)
+	pic.Distance = *distance || *colorMode == "distance"
+	if pic.Distance && !differentiable {
+		log.Fatalf("distance estimation is not available for the formula %s", formula)
+	}
+	pic.Interior = *interior || *colorMode == "period"
+	pic.Samples = *samples
+	pic.Sampling = samplingPattern
+	pic.AdaptiveDepth = *adaptive
+	pic.AdaptiveThreshold = *adaptiveThreshold
+	pic.Strategy = strategy
+	pic.Order = order
+	pic.Scheduler = scheduler
+	pic.DisableSymmetry = !*symmetry
+	pic.Precision = *precision
+	if pic.Precision == 0 {
+		pic.Precision = mandelbrot.PrecisionFor(pic.TopLeft, *areaSize/float64(*width))
+	}
+	if pic.Precision > 0 && formula.String() != "mandelbrot" {
+		log.Printf("WARNING: High precision is only available for the mandelbrot formula, %s will be calculated with float64", formula)
+		pic.Precision = 0
+	}
+	if pic.Precision > 0 && (pic.Samples > 1 || pic.AdaptiveDepth > 0) {
+		log.Printf("WARNING: Supersampling is not available with high precision, a single sample per pixel will be used")
+	}
+	if pic.Precision > 0 && pic.Strategy != mandelbrot.BruteForceStrategy {
+		log.Printf("WARNING: The %s strategy is not available with high precision, every point will be calculated", pic.Strategy)
+	}
+	if pic.Precision > 0 {
+		log.Printf("Using %d bits of precision", pic.Precision)
+		if useView {
+			center, err := parseCenter(*centerRe, *centerIm, *left, *top, *areaSize, *width, *height, pic.Precision)
+			if err != nil {
+				log.Fatalf("invalid center position, cause: %s", err)
+			}
+			view.BigCenter = &center
+			pic.BigTopLeft = view.BigTopLeft()
+		} else {
+			bigTopLeft, err := mandelbrot.ParseBigComplex(*left, *top, pic.Precision)
+			if err != nil {
+				log.Fatalf("invalid top left position, cause: %s", err)
+			}
+			pic.BigTopLeft = &bigTopLeft
+		}
+		pic.Perturbation = *perturbation
+	}
 	pic.Init()
 
+	var img *image.RGBA
+	partialPath := *out + ".partial"
+	arguments := strings.Join(resultArguments(), " ")
+	if *checkpoint != "" {
+		pointArguments := checkpointArguments(pic)
+		restored, err := loadCheckpoint(*checkpoint, pointArguments)
+		if err != nil {
+			log.Fatalf("checkpoint %s cannot be loaded, remove it to start a new calculation. cause: %s", *checkpoint, err)
+		}
+		if restored != nil {
+			log.Printf("Checkpoint %s restored", *checkpoint)
+			// The order and the scheduler can change between the runs.
+			restored.Order, restored.Scheduler = pic.Order, pic.Scheduler
+			pic = restored
+			img = paintComplete(pic, colorize)
+		}
+		stopCheckpoints := saveCheckpoints(*checkpoint, pointArguments, *checkpointInterval, pic)
+		defer func() {
+			stopCheckpoints()
+			if err := saveCheckpoint(*checkpoint, pointArguments, pic); err != nil {
+				log.Fatalf("checkpoint cannot be written, cause: %s", err)
+			}
+		}()
+	} else {
+		img, err = resumeImage(partialPath, arguments, pic)
+		if img != nil && *raw != "" {
+			log.Printf("WARNING: The raw file is not written when resuming from the image, use -checkpoint to keep the points of the calculation")
+			*raw = ""
+		}
+		if err != nil {
+			log.Printf("The previous calculation cannot be resumed, cause: %s", err)
+		}
+	}
+
 	log.Printf("Calculation started")
 
-	img, err := Calculate(*timeout, *workers, pic)
+	switch {
+	case img != nil:
+		log.Printf("Resuming %d missing areas", len(pic.Missing()))
+		err = Resume(*timeout, *workers, pic, colorize, img)
+	case *progressive > 1:
+		img, err = CalculateProgressive(*timeout, *workers, *progressive, pic, colorize, func(img *image.RGBA, step int) {
+			// The last pass is written at the end.
+			if step == 1 {
+				return
+			}
+			if err := writeImage(*out, img); err != nil {
+				log.Fatalf("output file cannot be written, cause: %s", err)
+			}
+			log.Printf("Pass %d written", step)
+		})
+	default:
+		img, err = Calculate(*timeout, *workers, pic, colorize)
+	}
 	if err != nil {
-		log.Printf("Calculation failed, image is not complete. cause: %s", err)
+		log.Printf("Calculation failed, image is not complete, run the same command again to calculate the missing areas. cause: %s", err)
+	}
+
+	if err := writeImage(*out, img); err != nil {
+		log.Fatalf("output file cannot be written, cause: %s", err)
+	}
+	switch {
+	case *raw == "":
+	case len(pic.Missing()) > 0:
+		log.Printf("The raw file is written when the calculation is complete")
+	default:
+		if err := writeRaw(*raw, pic, *rawCompress); err != nil {
+			log.Fatalf("raw file cannot be written, cause: %s", err)
+		}
+		log.Printf("Raw file %s written", *raw)
+	}
+	if *checkpoint != "" {
+		return
+	}
+	if err := writePartial(partialPath, arguments, pic, img); err != nil {
+		log.Fatalf("partial calculation cannot be written, cause: %s", err)
 	}
+}
 
-	outFile, err := os.Create(*out)
+// writeImage encodes the image in the format given by the extension of the path, it can be png or jpg.
+func writeImage(path string, img image.Image) error {
+	outFile, err := os.Create(path)
 	if err != nil {
-		log.Fatalf("output file cannot be opened, cause: %s", err)
+		return err
 	}
 	defer outFile.Close()
 
-	switch filepath.Ext(*out) {
+	switch filepath.Ext(path) {
 	case ".jpg", ".jpeg":
-		encodingError := jpeg.Encode(outFile, img, &jpeg.Options{Quality: 90})
-		if encodingError != nil {
-			panic(err)
-		}
+		return jpeg.Encode(outFile, img, &jpeg.Options{Quality: 90})
 	case ".png":
-		encodingError := png.Encode(outFile, img)
-		if encodingError != nil {
-			panic(err)
+		return png.Encode(outFile, img)
+	}
+	return nil
+}
+
+// parseCenter returns the center of the view with the given precision. A missing part of the center is zero, and if
+// both are missing it is the center of the area defined by the top left corner and the area size.
+func parseCenter(re, im, left, top string, areaSize float64, width, height int, precision uint) (mandelbrot.BigComplex, error) {
+	if re == "" && im == "" {
+		topLeft, err := mandelbrot.ParseBigComplex(left, top, precision)
+		if err != nil {
+			return mandelbrot.BigComplex{}, err
 		}
+		return topLeft.Add(complex(areaSize/2, -areaSize*float64(height)/float64(width)/2)), nil
+	}
+	if re == "" {
+		re = "0"
+	}
+	if im == "" {
+		im = "0"
 	}
+	return mandelbrot.ParseBigComplex(re, im, precision)
 }
 
-func Calculate(timeout int64, workers int, pic *mandelbrot.Picture) (*image.RGBA, error) {
+func Calculate(timeout int64, workers int, pic *mandelbrot.Picture, colorize colorizer) (*image.RGBA, error) {
+	img := image.NewRGBA(image.Rect(0, 0, pic.HorizontalResolution(), pic.VerticalResolution()))
+	err := paintCalculation(timeout, img, pic, colorize, func(ctx context.Context) <-chan int {
+		return pic.CalculateAsync(ctx, workers)
+	})
+	return img, err
+}
+
+// Resume calculates the areas of the picture that are not complete and paints them over img.
+func Resume(timeout int64, workers int, pic *mandelbrot.Picture, colorize colorizer, img *image.RGBA) error {
+	return paintCalculation(timeout, img, pic, colorize, func(ctx context.Context) <-chan int {
+		return pic.ResumeAsync(ctx, workers)
+	})
+}
+
+// paintCalculation paints the areas calculated by start in img. When the timeout is reached, it paints the complete
+// areas that were not sent yet and returns without waiting for the areas that are being calculated.
+func paintCalculation(timeout int64, img *image.RGBA, pic *mandelbrot.Picture, colorize colorizer, start func(ctx context.Context) <-chan int) error {
 	ctx, ctxCancel := context.WithTimeout(context.Background(), time.Second*time.Duration(timeout))
 	defer ctxCancel()
 	ctx, task := trace.NewTask(ctx, "Calculate")
 	defer task.End()
-	doneIndex := pic.CalculateAsync(ctx, workers)
-	img := image.NewRGBA(image.Rect(0, 0, pic.HorizontalResolution(), pic.VerticalResolution()))
 
+	areas := pic.HorizontalImageChunks * pic.VerticalImageChunks
+	painted := make([]bool, areas)
+	for i := 0; i < areas; i++ {
+		painted[i] = pic.Complete(i)
+	}
+	paint := func(i int) {
+		offsetX, offsetY := pic.GetImageOffsetFor(i)
+		paintAreaInImage(img, pic.GetArea(i), offsetX, offsetY, colorize)
+		painted[i] = true
+	}
+
+	doneIndex := start(ctx)
 	for {
 		select {
 		case <-ctx.Done():
 			log.Print("CANCEL")
-			return img, ctx.Err()
+			// The areas are not marked complete after the cancellation, so the complete ones can be painted while the
+			// areas that were being calculated finish.
+			for i := 0; i < areas; i++ {
+				if pic.Complete(i) && !painted[i] {
+					paint(i)
+				}
+			}
+			return ctx.Err()
 		case i, ok := <-doneIndex:
 			if !ok {
 				log.Print("Finished")
-				return img, nil
+				return nil
 			}
 			log.Printf("Index %d done", i)
+			paint(i)
+		}
+	}
+}
+
+// CalculateProgressive works like Calculate with the passes of Picture.CalculateProgressive, the image is painted
+// again after each pass and given to onPass with the step of the pass.
+func CalculateProgressive(timeout int64, workers int, step int, pic *mandelbrot.Picture, colorize colorizer, onPass func(img *image.RGBA, step int)) (*image.RGBA, error) {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), time.Second*time.Duration(timeout))
+	defer ctxCancel()
+	ctx, task := trace.NewTask(ctx, "CalculateProgressive")
+	defer task.End()
+	img := image.NewRGBA(image.Rect(0, 0, pic.HorizontalResolution(), pic.VerticalResolution()))
+
+	pic.CalculateProgressive(ctx, workers, step, func(step int) {
+		for i := 0; i < pic.HorizontalImageChunks*pic.VerticalImageChunks; i++ {
 			offsetX, offsetY := pic.GetImageOffsetFor(i)
-			paintAreaInImage(img, pic.GetArea(i), offsetX, offsetY)
+			paintAreaInImage(img, pic.GetArea(i), offsetX, offsetY, colorize)
 		}
+		onPass(img, step)
+	})
+	if ctx.Err() != nil {
+		log.Print("CANCEL")
+		// The areas of the last pass that are complete are not painted by onPass.
+		for i := 0; i < pic.HorizontalImageChunks*pic.VerticalImageChunks; i++ {
+			if pic.Complete(i) {
+				offsetX, offsetY := pic.GetImageOffsetFor(i)
+				paintAreaInImage(img, pic.GetArea(i), offsetX, offsetY, colorize)
+			}
+		}
+		return img, ctx.Err()
 	}
+	log.Print("Finished")
+	return img, nil
+}
+
+// colorizer gives the color for a point of the given area
+type colorizer func(point mandelbrot.Point, area *mandelbrot.Area) color.RGBA
+
+var colorizers = map[string]colorizer{
+	"bands": func(point mandelbrot.Point, area *mandelbrot.Area) color.RGBA {
+		return getColor(point, palette, area.MaxIterations, maxIterationsColor)
+	},
+	"smooth": func(point mandelbrot.Point, area *mandelbrot.Area) color.RGBA {
+		return getSmoothColor(point, palette, area.MaxIterations, maxIterationsColor)
+	},
+	"distance": func(point mandelbrot.Point, area *mandelbrot.Area) color.RGBA {
+		return getDistanceColor(point, area.PixelSize(), maxIterationsColor)
+	},
+	"period": func(point mandelbrot.Point, area *mandelbrot.Area) color.RGBA {
+		return getPeriodColor(point, palette, area.MaxIterations, maxIterationsColor)
+	},
 }
 
-func paintAreaInImage(img *image.RGBA, area mandelbrot.Area, offsetX int, offsetY int) {
+var palette = []color.RGBA{
+	color.RGBA{
+		R: 255,
+		A: 255,
+	},
+	color.RGBA{
+		G: 255,
+		A: 255,
+	},
+	color.RGBA{
+		B: 255,
+		A: 255,
+	},
+	color.RGBA{
+		R: 255,
+		G: 255,
+		A: 255,
+	},
+	color.RGBA{
+		G: 255,
+		B: 255,
+		A: 255,
+	},
+	color.RGBA{
+		R: 255,
+		B: 255,
+		A: 255,
+	},
+	color.RGBA{
+		R: 255,
+		G: 255,
+		B: 255,
+		A: 255,
+	},
+}
+
+var maxIterationsColor = color.RGBA{
+	A: 255,
+}
+
+// paintAreaInImage colors each pixel of the area with the average color of its samples.
+func paintAreaInImage(img *image.RGBA, area mandelbrot.Area, offsetX int, offsetY int, colorize colorizer) {
 	for x := 0; x < area.HorizontalResolution; x++ {
 		for y := 0; y < area.VerticalResolution; y++ {
-			point := area.GetPoint(x, y)
-			color := getColor(point, []color.RGBA{
-				color.RGBA{
-					R: 255,
-					A: 255,
-				},
-				color.RGBA{
-					G: 255,
-					A: 255,
-				},
-				color.RGBA{
-					B: 255,
-					A: 255,
-				},
-				color.RGBA{
-					R: 255,
-					G: 255,
-					A: 255,
-				},
-				color.RGBA{
-					G: 255,
-					B: 255,
-					A: 255,
-				},
-				color.RGBA{
-					R: 255,
-					B: 255,
-					A: 255,
-				},
-				color.RGBA{
-					R: 255,
-					G: 255,
-					B: 255,
-					A: 255,
-				},
-			}, area.MaxIterations, color.RGBA{
-				A: 255,
+			samples := area.SampleCount(x, y)
+			if samples == 1 {
+				img.SetRGBA(offsetX+x, offsetY+y, colorize(area.GetPoint(x, y), &area))
+				continue
+			}
+			var r, g, b, a int
+			for s := 0; s < samples; s++ {
+				c := colorize(area.GetSample(x, y, s), &area)
+				r, g, b, a = r+int(c.R), g+int(c.G), b+int(c.B), a+int(c.A)
+			}
+			img.SetRGBA(offsetX+x, offsetY+y, color.RGBA{
+				R: uint8(r / samples),
+				G: uint8(g / samples),
+				B: uint8(b / samples),
+				A: uint8(a / samples),
 			})
-			img.SetRGBA(offsetX+x, offsetY+y, color)
 		}
 	}
 }
@@ -142,3 +489,53 @@ func getColor(point mandelbrot.Point, palette []color.RGBA, maxIterations int, m
 	index := point.Iterations() % len(palette)
 	return palette[index]
 }
+
+// getSmoothColor interpolates between the palette colors using the continuous iteration count to avoid bands.
+func getSmoothColor(point mandelbrot.Point, palette []color.RGBA, maxIterations int, maxIterationsColor color.RGBA) color.RGBA {
+	if !point.Escaped() {
+		return maxIterationsColor
+	}
+	smooth := math.Max(point.SmoothIterations(), 0)
+	index := int(smooth)
+	fraction := smooth - float64(index)
+	from := palette[index%len(palette)]
+	to := palette[(index+1)%len(palette)]
+	return color.RGBA{
+		R: lerp(from.R, to.R, fraction),
+		G: lerp(from.G, to.G, fraction),
+		B: lerp(from.B, to.B, fraction),
+		A: lerp(from.A, to.A, fraction),
+	}
+}
+
+// getDistanceColor draws the boundary of the set in black and fades to white with the distance, measured in pixels.
+func getDistanceColor(point mandelbrot.Point, pixelSize float64, maxIterationsColor color.RGBA) color.RGBA {
+	if !point.Escaped() {
+		return maxIterationsColor
+	}
+	brightness := uint8(255 * math.Tanh(point.Distance()/pixelSize/2))
+	return color.RGBA{
+		R: brightness,
+		G: brightness,
+		B: brightness,
+		A: 255,
+	}
+}
+
+// getPeriodColor paints the interior points by the period of their orbit and the exterior with bands.
+func getPeriodColor(point mandelbrot.Point, palette []color.RGBA, maxIterations int, maxIterationsColor color.RGBA) color.RGBA {
+	if !point.Interior() {
+		return getColor(point, palette, maxIterations, maxIterationsColor)
+	}
+	from := palette[(point.Period()-1)%len(palette)]
+	return color.RGBA{
+		R: from.R / 2,
+		G: from.G / 2,
+		B: from.B / 2,
+		A: from.A,
+	}
+}
+
+func lerp(from, to uint8, fraction float64) uint8 {
+	return uint8(float64(from) + (float64(to)-float64(from))*fraction)
+}
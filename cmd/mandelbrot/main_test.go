@@ -3,8 +3,10 @@ package main
 import (
 	"image"
 	"image/jpeg"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/metalblueberry/mandelbrot/mandelbrot"
@@ -21,10 +23,12 @@ func TestSimpleRenderTrace(t *testing.T) {
 	// 	VerticalImageChunks:   2,
 	// 	ChunkImageSize:        512,
 	// }
-	pic := mandelbrot.NewPicture(complex(-1.401854499759, -0.000743603637), 0.00021646*1024, 1024, 32, 1000)
-	var err error
+	pic, err := mandelbrot.NewPicture(complex(-1.401854499759, -0.000743603637), 0.00021646*1024, 1024, 32, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
 	pic.Init()
-	result, err = Calculate(100, 6, pic)
+	result, err = Calculate(100, 6, pic, colorizers["bands"])
 	if err != nil {
 		log.Panic(err)
 	}
@@ -40,3 +44,155 @@ func TestSimpleRenderTrace(t *testing.T) {
 		panic(err)
 	}
 }
+
+func TestResumePartial(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mandelbrot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The image is kept as png with the partial file, so the lossy jpg output does not change the resumed areas.
+	partialPath := filepath.Join(dir, "out.jpg.partial")
+
+	newPicture := func() *mandelbrot.Picture {
+		pic, err := mandelbrot.NewPicture(complex(-2, 1.5), 3, 40, 4, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pic.Init()
+		return pic
+	}
+	expected := newPicture()
+	complete, err := Calculate(100, 2, expected, colorizers["bands"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A calculation where only some areas are complete.
+	partial := newPicture()
+	for _, i := range []int{1, 2, 7} {
+		partial.MarkComplete(i)
+	}
+	if err := writePartial(partialPath, "-maxIterations=50", partial, complete); err != nil {
+		t.Fatal(err)
+	}
+
+	if img, err := resumeImage(partialPath, "-maxIterations=60", newPicture()); img != nil || err == nil {
+		t.Error("Expected an error for different arguments")
+	}
+	resumed := newPicture()
+	img, err := resumeImage(partialPath, "-maxIterations=50", resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := resumed.Missing(); len(missing) != 13 || resumed.Complete(0) || !resumed.Complete(7) {
+		t.Errorf("Expected the areas 1, 2 and 7 complete, missing %v", missing)
+	}
+	if err := Resume(100, 2, resumed, colorizers["bands"], img); err != nil {
+		t.Fatal(err)
+	}
+	for i := range img.Pix {
+		if img.Pix[i] != complete.Pix[i] {
+			t.Fatalf("Resumed image differs at byte %d", i)
+		}
+	}
+
+	if err := writePartial(partialPath, "-maxIterations=50", resumed, img); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{partialPath, partialPath + ".png"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s removed when the picture is complete", path)
+		}
+	}
+}
+
+func TestCheckpointFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mandelbrot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint")
+
+	if pic, err := loadCheckpoint(path, "-maxIterations=50"); pic != nil || err != nil {
+		t.Fatalf("Expected no checkpoint, got %v", err)
+	}
+	pic, err := mandelbrot.NewPicture(complex(-2, 1.5), 3, 40, 4, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	pic.MarkComplete(3)
+	if err := saveCheckpoint(path, "-maxIterations=50", pic); err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := loadCheckpoint(path, "-maxIterations=60"); restored != nil || err == nil {
+		t.Error("Expected an error for different arguments")
+	}
+	restored, err := loadCheckpoint(path, "-maxIterations=50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.Missing()) != 15 || !restored.Complete(3) {
+		t.Errorf("Expected the area 3 complete, missing %v", restored.Missing())
+	}
+
+	if _, err := Calculate(100, 2, restored, colorizers["bands"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveCheckpoint(path, "-maxIterations=50", restored); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected the checkpoint removed when the picture is complete")
+	}
+}
+
+func TestRecolor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mandelbrot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rawPath, imagePath := filepath.Join(dir, "out.mbr"), filepath.Join(dir, "out.png")
+
+	pic, err := mandelbrot.NewPicture(complex(-2, 1.5), 3, 40, 3, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Distance = true
+	pic.Init()
+	expected, err := Calculate(100, 2, pic, colorizers["smooth"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeRaw(rawPath, pic, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := recolor([]string{"-color", "period", "-out", imagePath, rawPath}); err == nil {
+		t.Error("Expected an error for a raw file without interior detection")
+	}
+	if err := recolor([]string{"-color", "smooth", "-out", imagePath, rawPath}); err != nil {
+		t.Fatal(err)
+	}
+	imageFile, err := os.Open(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imageFile.Close()
+	img, _, err := image.Decode(imageFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 40; y++ {
+			r1, g1, b1, _ := expected.At(x, y).RGBA()
+			r2, g2, b2, _ := img.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 {
+				t.Fatalf("Pixel %d,%d expected %v, got %v", x, y, expected.At(x, y), img.At(x, y))
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+// The partial file is written next to the image when the calculation is not complete. It has the arguments of the
+// command in the first line and the indexes of the complete areas separated by spaces in the second one. The image is
+// also kept as png next to the partial file, so the complete areas are not degraded by a lossy output format every
+// time the calculation is resumed.
+
+// partialImagePath returns the path of the image kept with the partial file.
+func partialImagePath(partialPath string) string {
+	return partialPath + ".png"
+}
+
+// resultArguments returns the flags set in the command line that change the result of the calculation. The ones
+// that only change how it is done are ignored, so they can be changed to resume it, and also the ignored ones.
+func resultArguments(ignored ...string) []string {
+	var arguments []string
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "timeout", "workers", "order", "scheduler", "progressive", "out", "raw", "rawCompress", "checkpoint", "checkpointInterval":
+			return
+		}
+		for _, name := range ignored {
+			if f.Name == name {
+				return
+			}
+		}
+		arguments = append(arguments, "-"+f.Name+"="+f.Value.String())
+	})
+	return arguments
+}
+
+// writePartial writes the partial file and the image of the picture, or removes them if the picture is complete.
+func writePartial(path string, arguments string, pic *mandelbrot.Picture, img image.Image) error {
+	if len(pic.Missing()) == 0 {
+		for _, removePath := range []string{path, partialImagePath(path)} {
+			if err := os.Remove(removePath); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := writeImage(partialImagePath(path), img); err != nil {
+		return err
+	}
+
+	complete := make([]string, 0)
+	for i := 0; i < pic.HorizontalImageChunks*pic.VerticalImageChunks; i++ {
+		if pic.Complete(i) {
+			complete = append(complete, strconv.Itoa(i))
+		}
+	}
+	return ioutil.WriteFile(path, []byte(arguments+"\n"+strings.Join(complete, " ")+"\n"), 0644)
+}
+
+// resumeImage returns the image of a previous calculation with the same arguments and marks its complete areas in the
+// picture. It returns nil if there is no partial file.
+func resumeImage(partialPath string, arguments string, pic *mandelbrot.Picture) (*image.RGBA, error) {
+	partialFile, err := os.Open(partialPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer partialFile.Close()
+
+	scanner := bufio.NewScanner(partialFile)
+	scanner.Buffer(nil, 1<<24)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) != 2 {
+		return nil, errors.New("the partial file is not valid")
+	}
+	if lines[0] != arguments {
+		return nil, fmt.Errorf("it was calculated with the arguments %s", lines[0])
+	}
+
+	var complete []int
+	for _, field := range strings.Fields(lines[1]) {
+		i, err := strconv.Atoi(field)
+		if err != nil || i < 0 || i >= pic.HorizontalImageChunks*pic.VerticalImageChunks {
+			return nil, fmt.Errorf("the partial file has an invalid area %s", field)
+		}
+		complete = append(complete, i)
+	}
+
+	imageFile, err := os.Open(partialImagePath(partialPath))
+	if err != nil {
+		return nil, err
+	}
+	defer imageFile.Close()
+	previous, _, err := image.Decode(imageFile)
+	if err != nil {
+		return nil, err
+	}
+	bounds := image.Rect(0, 0, pic.HorizontalResolution(), pic.VerticalResolution())
+	if previous.Bounds() != bounds {
+		return nil, fmt.Errorf("the image size is %s instead of %s", previous.Bounds().Size(), bounds.Size())
+	}
+
+	img := image.NewRGBA(bounds)
+	draw.Draw(img, bounds, previous, image.Point{}, draw.Src)
+	for _, i := range complete {
+		pic.MarkComplete(i)
+	}
+	return img, nil
+}
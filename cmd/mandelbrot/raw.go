@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+// writeRaw writes the raw data of the picture, see mandelbrot.Picture.SaveRaw.
+func writeRaw(path string, pic *mandelbrot.Picture, compress bool) error {
+	rawFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = pic.SaveRaw(rawFile, compress)
+	if closeErr := rawFile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// readRaw returns the picture saved in a raw file.
+func readRaw(path string) (*mandelbrot.Picture, error) {
+	rawFile, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rawFile.Close()
+	return mandelbrot.LoadRaw(rawFile)
+}
+
+// recolor is the recolor command, it paints the image of a raw file written with -raw without calculating it again.
+func recolor(args []string) error {
+	flags := flag.NewFlagSet("recolor", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s recolor [flags] file.mbr\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	colorMode := flags.String("color", "bands", "Coloring mode, it can be bands, smooth, distance or period")
+	out := flags.String("out", "mandelbrot.jpg", "output file, it can be png or jpg")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected a raw file, got %d arguments", flags.NArg())
+	}
+
+	colorize, ok := colorizers[*colorMode]
+	if !ok {
+		return fmt.Errorf("unknown color mode %s", *colorMode)
+	}
+	pic, err := readRaw(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("raw file cannot be read, cause: %s", err)
+	}
+	if *colorMode == "distance" && !pic.Distance {
+		return fmt.Errorf("the raw file has no distance estimation, write it with -distance")
+	}
+	if *colorMode == "period" && !pic.Interior {
+		return fmt.Errorf("the raw file has no interior detection")
+	}
+	log.Printf("Recoloring %dx%d pixels", pic.HorizontalResolution(), pic.VerticalResolution())
+
+	if err := writeImage(*out, paintComplete(pic, colorize)); err != nil {
+		return fmt.Errorf("output file cannot be written, cause: %s", err)
+	}
+	return nil
+}
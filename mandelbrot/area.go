@@ -1,13 +1,67 @@
 package mandelbrot
 
+import (
+	"math"
+	"math/big"
+	"math/cmplx"
+)
+
 // Area represents a mandelbrot area that will be computed in a single execution.
 type Area struct {
 	HorizontalResolution int
 	VerticalResolution   int
-	TopLeft              complex128
-	BottomRight          complex128
-	MaxIterations        int
-	Points               []Point
+	// TopLeft and BottomRight are the corners of the area before the rotation.
+	TopLeft     complex128
+	BottomRight complex128
+	// Rotation is the angle in radians that the area is turned counterclockwise around its center.
+	Rotation      float64
+	MaxIterations int
+	// Bailout is the escape radius, DefaultBailout is used when it is zero.
+	Bailout float64
+	// Julia calculates the Julia set of C instead of the Mandelbrot set, the points of the area are the starting values of z.
+	Julia bool
+	C     complex128
+	// Formula is the function iterated for each point, the Mandelbrot formula is used when it is nil.
+	Formula Formula
+	// Distance enables the distance estimation of the points, see Point.Distance.
+	Distance bool
+	// Interior stops early the points proven to be inside the set, see Params.Interior.
+	Interior bool
+	// Samples is the number of points calculated for each pixel so the coloring can combine them to reduce aliasing.
+	// A single point is calculated when it is lower than 2. Supersampling is not available with Precision.
+	Samples int
+	// Sampling is the pattern of the samples inside each pixel.
+	Sampling SamplingPattern
+	// AdaptiveDepth enables the adaptive supersampling, it replaces Samples. Only the pixels whose iterations differ
+	// from a neighbor by more than AdaptiveThreshold are supersampled with a grid of 2x2 samples, and while their
+	// samples still differ by more than AdaptiveThreshold the grid is doubled up to AdaptiveDepth times.
+	AdaptiveDepth     int
+	AdaptiveThreshold float64
+	// Strategy chooses how the points are visited, BruteForceStrategy is used by default.
+	// Other strategies are not available with Precision.
+	Strategy Strategy
+	// Precision is the number of bits used in the calculation. When it is not zero, the points are calculated with math/big.
+	// High precision and perturbation are only available for the Mandelbrot formula, they are ignored for other formulas.
+	Precision uint
+	// BigTopLeft and BigBottomRight are the high precision versions of TopLeft and BottomRight used when Precision is set.
+	// If they are nil, TopLeft and BottomRight are used instead.
+	BigTopLeft     *BigComplex
+	BigBottomRight *BigComplex
+	// Reference enables the perturbation method, the points are calculated as deltas against the reference orbit.
+	// The delta between the area and the reference is computed with Precision.
+	Reference *Reference
+	Points    []Point
+
+	bigPoints []BigPoint
+	// step is the distance between the points calculated by a pass of Picture.CalculateProgressive, all the points
+	// are calculated when it is lower than 2.
+	step int
+	// samples holds the samples of each pixel except the first one, that is stored in Points.
+	samples [][]Point
+	// bigSpan is the span between the high precision corners, Init calculates it once because the subtraction is
+	// expensive and it is needed for every point.
+	bigSpan    complex128
+	hasBigSpan bool
 }
 
 // NewAreaCentered creates a mandelbrot.Area with squared shape centered area at x,y of width = 2*area
@@ -21,8 +75,22 @@ func NewAreaCentered(Resolution, MaxIterations int, x, y, area float64) *Area {
 	}
 }
 
+// NewRectangularAreaCentered creates a mandelbrot.Area centered at x,y of width = 2*area and the height that keeps
+// the pixels square for the given resolution.
+func NewRectangularAreaCentered(HorizontalResolution, VerticalResolution, MaxIterations int, x, y, area float64) *Area {
+	height := area * float64(VerticalResolution) / float64(HorizontalResolution)
+	return &Area{
+		HorizontalResolution: HorizontalResolution,
+		VerticalResolution:   VerticalResolution,
+		MaxIterations:        MaxIterations,
+		TopLeft:              complex(x-area, y+height),
+		BottomRight:          complex(x+area, y-height),
+	}
+}
+
 // Init allocates the necessary memory to perform the calculation. it is required to call this function before calling Calculate
 func (a *Area) Init() {
+	a.initSpan()
 	a.Points = make([]Point, a.VerticalResolution*a.HorizontalResolution)
 	for x := 0; x < a.HorizontalResolution; x++ {
 		for y := 0; y < a.VerticalResolution; y++ {
@@ -30,13 +98,135 @@ func (a *Area) Init() {
 			a.SetPoint(x, y, point)
 		}
 	}
+	a.bigPoints = nil
+	if a.Precision > 0 && a.Reference == nil && isMandelbrot(a.Formula) {
+		a.initBig()
+	}
+	a.samples = nil
+	if a.Precision == 0 && a.Reference == nil {
+		switch {
+		case a.AdaptiveDepth > 0:
+			a.samples = make([][]Point, len(a.Points))
+		case a.Samples > 1:
+			a.initSamples()
+		}
+	}
+}
+
+// initBig allocates the high precision points, they are calculated in parallel to Points and their result is copied back after each calculation.
+func (a *Area) initBig() {
+	topLeft := a.bigTopLeft()
+	a.bigPoints = make([]BigPoint, len(a.Points))
+	for i := range a.bigPoints {
+		x, y := a.ForIndex(i)
+		a.bigPoints[i] = NewBigPoint(topLeft.Add(a.getOffset(x, y)))
+		a.Points[i].Point = a.bigPoints[i].Point.Complex128()
+	}
+}
+
+// bigTopLeft returns the top left corner with the precision of the area.
+func (a *Area) bigTopLeft() BigComplex {
+	if a.BigTopLeft == nil {
+		return NewBigComplex(a.TopLeft, a.Precision)
+	}
+	return BigComplex{
+		Real: new(big.Float).SetPrec(a.Precision).Set(a.BigTopLeft.Real),
+		Imag: new(big.Float).SetPrec(a.Precision).Set(a.BigTopLeft.Imag),
+	}
+}
+
+// span returns the distance from TopLeft to BottomRight.
+// initSpan calculates the span of the high precision corners once, the float64 corners are too close at deep zooms.
+func (a *Area) initSpan() {
+	a.hasBigSpan = false
+	if a.BigTopLeft != nil && a.BigBottomRight != nil {
+		a.bigSpan, a.hasBigSpan = a.BigBottomRight.Sub(*a.BigTopLeft), true
+	}
+}
+
+func (a *Area) span() complex128 {
+	if a.hasBigSpan {
+		return a.bigSpan
+	}
+	if a.BigTopLeft != nil && a.BigBottomRight != nil {
+		return a.BigBottomRight.Sub(*a.BigTopLeft)
+	}
+	return a.BottomRight - a.TopLeft
 }
 
 // Calculate performs the iterations for each point.
+// With the perturbation method, the points are calculated from the start on each call instead of continuing their orbits.
 func (a *Area) Calculate() {
-	for i := 0; i < len(a.Points); i++ {
-		a.Points[i].Calculate(a.MaxIterations)
+	if a.Reference != nil && isMandelbrot(a.Formula) {
+		a.calculatePerturbation()
+		return
 	}
+	params := a.params()
+	if a.bigPoints != nil {
+		for i := 0; i < len(a.bigPoints); i++ {
+			a.bigPoints[i].CalculateParams(params)
+			a.Points[i] = a.bigPoints[i].point()
+		}
+		return
+	}
+	if a.step > 1 {
+		a.calculatePass(params)
+		return
+	}
+	switch a.Strategy {
+	case SubdivisionStrategy:
+		a.calculateSubdivision(params)
+	case BoundaryTracingStrategy:
+		a.calculateBoundaryTracing(params)
+	default:
+		for i := 0; i < len(a.Points); i++ {
+			a.Points[i].CalculateParams(params)
+		}
+	}
+	for i := range a.samples {
+		for j := range a.samples[i] {
+			a.samples[i][j].CalculateParams(params)
+		}
+	}
+	if a.AdaptiveDepth > 0 && a.samples != nil {
+		a.refine(params)
+	}
+}
+
+// params returns the settings used to iterate the points of the area.
+func (a *Area) params() Params {
+	return Params{
+		MaxIterations: a.MaxIterations,
+		Bailout:       a.Bailout,
+		Julia:         a.Julia,
+		C:             a.C,
+		Formula:       a.Formula,
+		Distance:      a.Distance,
+		Interior:      a.Interior,
+	}
+}
+
+// Deepen raises MaxIterations and continues the calculation of every point from where it stopped.
+// The Reference is extended too, so areas that share it must not be deepened concurrently, see Picture.Deepen.
+func (a *Area) Deepen(MaxIterations int) {
+	a.MaxIterations = MaxIterations
+	if a.Reference != nil && isMandelbrot(a.Formula) {
+		a.Reference.Calculate(a.params())
+	}
+	a.Calculate()
+}
+
+// PixelSize returns the horizontal distance between two consecutive points, it gives scale to Point.Distance.
+func (a *Area) PixelSize() float64 {
+	return math.Abs(real(a.span())) / float64(a.HorizontalResolution)
+}
+
+// Corners returns the corners of the area after the rotation.
+func (a *Area) Corners() (topLeft, topRight, bottomLeft, bottomRight complex128) {
+	return a.TopLeft + a.getOffset(0, 0),
+		a.TopLeft + a.getOffset(a.HorizontalResolution, 0),
+		a.TopLeft + a.getOffset(0, a.VerticalResolution),
+		a.TopLeft + a.getOffset(a.HorizontalResolution, a.VerticalResolution)
 }
 
 // IndexFor is an utility function to locate a x,y coordinate in the Points slice
@@ -61,14 +251,47 @@ func (a *Area) GetPoint(x, y int) Point {
 	return a.Points[a.IndexFor(x, y)]
 }
 
+// SampleCount returns the number of samples calculated for the pixel located at x,y.
+func (a *Area) SampleCount(x, y int) int {
+	if a.samples == nil {
+		return 1
+	}
+	return 1 + len(a.samples[a.IndexFor(x, y)])
+}
+
+// GetSample returns the sample s of the pixel located at x,y. The sample 0 is the point returned by GetPoint.
+func (a *Area) GetSample(x, y, s int) Point {
+	if s == 0 {
+		return a.GetPoint(x, y)
+	}
+	return a.samples[a.IndexFor(x, y)][s-1]
+}
+
 // getNumber gives the real and imaginary parts for the complex number located at the given x,y coordinates in the given resolution.
 func (a *Area) getNumber(x, y int) (r, i float64) {
-	TopLeftReal := real(a.TopLeft)
-	TopLeftImag := imag(a.TopLeft)
-	BottomRightReal := real(a.BottomRight)
-	BottomRightImag := imag(a.BottomRight)
-
-	r = TopLeftReal + (float64(x)/float64(a.HorizontalResolution))*(BottomRightReal-TopLeftReal)
-	i = TopLeftImag + (float64(y)/float64(a.VerticalResolution))*(BottomRightImag-TopLeftImag)
-	return r, i
+	offset := a.getOffset(x, y)
+	return real(a.TopLeft) + real(offset), imag(a.TopLeft) + imag(offset)
+}
+
+// getOffset gives the distance from TopLeft to the complex number located at the given x,y coordinates.
+func (a *Area) getOffset(x, y int) complex128 {
+	return a.getOffsetAt(float64(x), float64(y))
+}
+
+// getOffsetAt works like getOffset for positions between pixels.
+func (a *Area) getOffsetAt(x, y float64) complex128 {
+	span := a.span()
+	offset := complex(
+		(x/float64(a.HorizontalResolution))*real(span),
+		(y/float64(a.VerticalResolution))*imag(span),
+	)
+	if a.Rotation == 0 {
+		return offset
+	}
+	return rotate(offset-span/2, a.Rotation) + span/2
+}
+
+// rotate turns c counterclockwise by angle radians around the origin.
+func rotate(c complex128, angle float64) complex128 {
+	return c * cmplx.Rect(1, angle)
 }
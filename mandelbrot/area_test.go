@@ -1,11 +1,136 @@
 package mandelbrot_test
 
 import (
+	"math"
+	"math/cmplx"
 	"testing"
 
 	"github.com/metalblueberry/mandelbrot/mandelbrot"
 )
 
+func TestAreaDeepen(t *testing.T) {
+	newArea := func(maxIterations int) *mandelbrot.Area {
+		area := &mandelbrot.Area{
+			HorizontalResolution: 50,
+			VerticalResolution:   40,
+			MaxIterations:        maxIterations,
+			TopLeft:              complex(-1.401854499759, -0.000743603637),
+			BottomRight:          complex(-1.399689899172, 0.000743603637),
+		}
+		area.Init()
+		return area
+	}
+
+	oneShot := newArea(10000)
+	oneShot.Calculate()
+
+	incremental := newArea(100)
+	incremental.Calculate()
+	incremental.Deepen(1000)
+	incremental.Deepen(10000)
+
+	for i := range oneShot.Points {
+		if oneShot.Points[i] != incremental.Points[i] {
+			x, y := oneShot.ForIndex(i)
+			t.Errorf("Point %d,%d differs, one shot %d incremental %d", x, y, oneShot.Points[i].Iterations(), incremental.Points[i].Iterations())
+		}
+	}
+}
+
+func TestRectangularAreaCentered(t *testing.T) {
+	area := mandelbrot.NewRectangularAreaCentered(160, 90, 100, -0.5, 0, 2)
+	if area.TopLeft != complex(-2.5, 1.125) || area.BottomRight != complex(1.5, -1.125) {
+		t.Errorf("Unexpected corners %f and %f", area.TopLeft, area.BottomRight)
+	}
+}
+
+func TestAreaRotation(t *testing.T) {
+	tests := []struct {
+		rotation float64
+		corners  [4]complex128
+	}{
+		{0, [4]complex128{complex(-1, 1), complex(1, 1), complex(-1, -1), complex(1, -1)}},
+		{math.Pi / 2, [4]complex128{complex(-1, -1), complex(-1, 1), complex(1, -1), complex(1, 1)}},
+		{math.Pi, [4]complex128{complex(1, -1), complex(-1, -1), complex(1, 1), complex(-1, 1)}},
+		{math.Pi / 4, [4]complex128{complex(-math.Sqrt2, 0), complex(0, math.Sqrt2), complex(0, -math.Sqrt2), complex(math.Sqrt2, 0)}},
+	}
+
+	for i, test := range tests {
+		area := mandelbrot.NewAreaCentered(4, 10, 0, 0, 1)
+		area.Rotation = test.rotation
+		area.Init()
+		topLeft, topRight, bottomLeft, bottomRight := area.Corners()
+		for j, corner := range [4]complex128{topLeft, topRight, bottomLeft, bottomRight} {
+			if cmplx.Abs(corner-test.corners[j]) > 1e-12 {
+				t.Errorf("Test %d failed, corner %d expected %f got %f", i, j, test.corners[j], corner)
+			}
+		}
+		if point := area.GetPoint(0, 0); cmplx.Abs(point.Point-topLeft) > 1e-12 {
+			t.Errorf("Test %d failed, first point %f is not at the top left corner %f", i, point.Point, topLeft)
+		}
+	}
+}
+
+func TestAreaSamples(t *testing.T) {
+	newArea := func(samples int) *mandelbrot.Area {
+		area := mandelbrot.NewAreaCentered(20, 200, -0.75, 0.1, 0.05)
+		area.Samples = samples
+		area.Init()
+		area.Calculate()
+		return area
+	}
+
+	single := newArea(1)
+	sampled := newArea(4)
+	for x := 0; x < single.HorizontalResolution; x++ {
+		for y := 0; y < single.VerticalResolution; y++ {
+			if single.SampleCount(x, y) != 1 || sampled.SampleCount(x, y) != 4 {
+				t.Fatalf("Expected 1 and 4 samples, got %d and %d", single.SampleCount(x, y), sampled.SampleCount(x, y))
+			}
+			if single.GetPoint(x, y) != sampled.GetSample(x, y, 0) {
+				t.Errorf("First sample of pixel %d,%d must be the point without supersampling", x, y)
+			}
+			for s := 1; s < sampled.SampleCount(x, y); s++ {
+				if sample := sampled.GetSample(x, y, s); sample.Iterations() == 0 {
+					t.Errorf("Sample %d of pixel %d,%d is not calculated", s, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestAreaPrecision(t *testing.T) {
+	topLeft, err := mandelbrot.ParseBigComplex("-2.00000000000000000000000000001", "0", 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bottomRight, err := mandelbrot.ParseBigComplex("-1.99999999999999999999999999999", "0", 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	area := mandelbrot.Area{
+		HorizontalResolution: 10,
+		VerticalResolution:   1,
+		MaxIterations:        1000,
+		Bailout:              4,
+		Precision:            128,
+		TopLeft:              topLeft.Complex128(),
+		BottomRight:          bottomRight.Complex128(),
+		BigTopLeft:           &topLeft,
+		BigBottomRight:       &bottomRight,
+	}
+	area.Init()
+	area.Calculate()
+
+	for x := 0; x < area.HorizontalResolution; x++ {
+		point := area.GetPoint(x, 0)
+		// The first half is at the left of -2, outside of the set.
+		if escapes := x < area.HorizontalResolution/2; point.Escaped() != escapes {
+			t.Errorf("Point %d escaped %t, expected %t", x, point.Escaped(), escapes)
+		}
+	}
+}
+
 func BenchmarkArea(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		b.StopTimer()
@@ -0,0 +1,216 @@
+package mandelbrot
+
+import (
+	"math"
+	"math/big"
+)
+
+// BigComplex is a complex number with arbitrary precision parts.
+type BigComplex struct {
+	Real *big.Float
+	Imag *big.Float
+}
+
+// NewBigComplex returns a BigComplex with the value of c and the given precision in bits.
+func NewBigComplex(c complex128, precision uint) BigComplex {
+	return BigComplex{
+		Real: new(big.Float).SetPrec(precision).SetFloat64(real(c)),
+		Imag: new(big.Float).SetPrec(precision).SetFloat64(imag(c)),
+	}
+}
+
+// ParseBigComplex parses the real and imaginary parts without losing the digits that do not fit in a float64.
+func ParseBigComplex(r, i string, precision uint) (BigComplex, error) {
+	re, _, err := big.ParseFloat(r, 10, precision, big.ToNearestEven)
+	if err != nil {
+		return BigComplex{}, err
+	}
+	im, _, err := big.ParseFloat(i, 10, precision, big.ToNearestEven)
+	if err != nil {
+		return BigComplex{}, err
+	}
+	return BigComplex{Real: re, Imag: im}, nil
+}
+
+// Add returns c + offset with the precision of c.
+func (c BigComplex) Add(offset complex128) BigComplex {
+	result := BigComplex{
+		Real: new(big.Float).SetPrec(c.Real.Prec()).SetFloat64(real(offset)),
+		Imag: new(big.Float).SetPrec(c.Imag.Prec()).SetFloat64(imag(offset)),
+	}
+	result.Real.Add(result.Real, c.Real)
+	result.Imag.Add(result.Imag, c.Imag)
+	return result
+}
+
+// Sub returns c - other rounded to a complex128.
+// It is exact enough to compute the distance between two close numbers that can't be told apart as complex128.
+func (c BigComplex) Sub(other BigComplex) complex128 {
+	r := new(big.Float).SetPrec(c.Real.Prec()).Sub(c.Real, other.Real)
+	i := new(big.Float).SetPrec(c.Imag.Prec()).Sub(c.Imag, other.Imag)
+	rf, _ := r.Float64()
+	imf, _ := i.Float64()
+	return complex(rf, imf)
+}
+
+// Complex128 returns the closest complex128 to c.
+func (c BigComplex) Complex128() complex128 {
+	r, _ := c.Real.Float64()
+	i, _ := c.Imag.Float64()
+	return complex(r, i)
+}
+
+// PrecisionFor returns the number of bits needed to tell apart pixels of the given size around coordinate.
+// It returns 0 when a complex128 is precise enough.
+func PrecisionFor(coordinate complex128, pixelSize float64) uint {
+	magnitude := math.Max(math.Max(math.Abs(real(coordinate)), math.Abs(imag(coordinate))), 1)
+	bits := math.Ceil(math.Log2(magnitude / pixelSize))
+	// 16 extra bits keep the rounding errors of the iteration away from the pixel size.
+	if bits+16 <= 53 {
+		return 0
+	}
+	// Long orbits at deep zooms amplify the rounding errors much more, so the high precision path gets a wider margin.
+	return uint(bits) + 48
+}
+
+// BigPoint is the arbitrary precision version of Point, it is used when the distance between points is too small for a complex128.
+type BigPoint struct {
+	Point      BigComplex
+	iterations int
+	zr         *big.Float
+	zi         *big.Float
+	escaped    bool
+	smooth     float64
+	dz         complex128
+	distance   float64
+}
+
+// NewBigPoint returns a new point at the given coordinates. The precision of the coordinates is used for the whole calculation.
+func NewBigPoint(point BigComplex) BigPoint {
+	return BigPoint{
+		Point: point,
+	}
+}
+
+// Calculate performs as many calculations as MaxIterations to determine if the point belongs to the set or not.
+// As with Point, the orbit is kept between calls.
+func (m *BigPoint) Calculate(MaxIterations int) {
+	m.CalculateParams(Params{MaxIterations: MaxIterations})
+}
+
+// CalculateParams works like Calculate but allows to customize the iteration with Params.
+func (m *BigPoint) CalculateParams(params Params) {
+	if m.escaped {
+		return
+	}
+	bailout := params.Bailout
+	if bailout == 0 {
+		bailout = DefaultBailout
+	}
+
+	precision := m.Point.Real.Prec()
+	newFloat := func() *big.Float { return new(big.Float).SetPrec(precision) }
+	if m.zr == nil {
+		m.zr, m.zi = newFloat(), newFloat()
+		if params.Julia {
+			m.zr.Set(m.Point.Real)
+			m.zi.Set(m.Point.Imag)
+		}
+	}
+	cr, ci := m.Point.Real, m.Point.Imag
+	if params.Julia {
+		cr, ci = newFloat().SetFloat64(real(params.C)), newFloat().SetFloat64(imag(params.C))
+	}
+
+	zr, zi := m.zr, m.zi
+	zr2, zi2, abs := newFloat(), newFloat(), newFloat()
+	bailoutSquared := newFloat().SetFloat64(bailout * bailout)
+	iterations := m.iterations
+
+	// The derivative doesn't need the full precision, it is tracked as a complex128.
+	dz, dc := m.dz, complex(1, 0)
+	if params.Julia {
+		dc = 0
+		if iterations == 0 {
+			dz = 1
+		}
+	}
+
+	for {
+		zr2.Mul(zr, zr)
+		zi2.Mul(zi, zi)
+		if abs.Add(zr2, zi2).Cmp(bailoutSquared) >= 0 {
+			m.escaped = true
+			break
+		}
+		if iterations >= params.MaxIterations {
+			break
+		}
+		iterations++
+		if params.Distance {
+			dz = 2*m.Z()*dz + dc
+		}
+		// z = z*z + c, the imaginary part must be computed first because it needs the previous real part.
+		zi.Mul(zi, zr)
+		zi.Add(zi, zi)
+		zi.Add(zi, ci)
+		zr.Sub(zr2, zi2)
+		zr.Add(zr, cr)
+	}
+
+	m.iterations = iterations
+	m.dz = dz
+	if params.Distance {
+		m.distance = distanceEstimation(m.Z(), dz)
+	}
+	if m.escaped {
+		m.smooth = smoothIterations(iterations, m.Z(), 2)
+	}
+}
+
+// Iterations returns the number of performed iterations.
+func (m *BigPoint) Iterations() int {
+	return m.iterations
+}
+
+// Escaped reports whether the orbit has exceeded the bailout radius.
+func (m *BigPoint) Escaped() bool {
+	return m.escaped
+}
+
+// SmoothIterations returns a continuous version of Iterations for escaped points.
+func (m *BigPoint) SmoothIterations() float64 {
+	if !m.escaped {
+		return float64(m.iterations)
+	}
+	return m.smooth
+}
+
+// Distance returns the estimated distance from an escaped point to the set, see Point.Distance.
+func (m *BigPoint) Distance() float64 {
+	if !m.escaped {
+		return 0
+	}
+	return m.distance
+}
+
+// Z returns the last value of the orbit rounded to a complex128.
+func (m *BigPoint) Z() complex128 {
+	if m.zr == nil {
+		return 0
+	}
+	return BigComplex{Real: m.zr, Imag: m.zi}.Complex128()
+}
+
+// point returns the results of the calculation as a Point located at the closest complex128.
+func (m *BigPoint) point() Point {
+	return Point{
+		Point:      m.Point.Complex128(),
+		iterations: m.iterations,
+		z:          m.Z(),
+		escaped:    m.escaped,
+		smooth:     m.smooth,
+		dz:         m.dz,
+		distance:   m.distance,
+	}
+}
@@ -0,0 +1,73 @@
+package mandelbrot_test
+
+import (
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+func TestBigPointMatchesPoint(t *testing.T) {
+	points := []complex128{
+		complex(1, 0),
+		complex(-1, 0),
+		complex(-0.5, 0.5),
+		complex(0.3, 0.5),
+		complex(-0.75, 0.2),
+	}
+
+	for i, c := range points {
+		point := NewPoint(real(c), imag(c))
+		point.Calculate(200)
+		bigPoint := NewBigPoint(NewBigComplex(c, 128))
+		bigPoint.Calculate(200)
+		if point.Iterations() != bigPoint.Iterations() || point.Escaped() != bigPoint.Escaped() {
+			t.Errorf("Test %d failed, Point %f iterations %d big iterations %d", i, c, point.Iterations(), bigPoint.Iterations())
+		}
+	}
+}
+
+func TestBigPointResume(t *testing.T) {
+	c, err := ParseBigComplex("-0.74364388703715870475219150611477", "0.13182590420531197049332740227943", 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneShot := NewBigPoint(c)
+	oneShot.Calculate(3000)
+
+	incremental := NewBigPoint(c)
+	for _, maxIterations := range []int{10, 100, 3000} {
+		incremental.Calculate(maxIterations)
+	}
+
+	if oneShot.Iterations() != incremental.Iterations() || oneShot.Z() != incremental.Z() {
+		t.Errorf("one shot %d %v incremental %d %v", oneShot.Iterations(), oneShot.Z(), incremental.Iterations(), incremental.Z())
+	}
+}
+
+func TestBigPointBeyondFloat64(t *testing.T) {
+	// -2 - 1e-30 is outside the set but it is rounded to -2 as a complex128, which never escapes.
+	c, err := ParseBigComplex("-2.000000000000000000000000000001", "0", 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := NewPoint(real(c.Complex128()), imag(c.Complex128()))
+	params := Params{MaxIterations: 1000, Bailout: 4}
+	point.CalculateParams(params)
+	if point.Escaped() {
+		t.Errorf("complex128 point is not expected to escape")
+	}
+	bigPoint := NewBigPoint(c)
+	bigPoint.CalculateParams(params)
+	if !bigPoint.Escaped() {
+		t.Errorf("big point is expected to escape, iterations %d", bigPoint.Iterations())
+	}
+}
+
+func TestPrecisionFor(t *testing.T) {
+	if bits := PrecisionFor(complex(-0.75, 0), 3.0/1920); bits != 0 {
+		t.Errorf("complex128 must be enough for a full view, got %d bits", bits)
+	}
+	if bits := PrecisionFor(complex(-0.75, 0), 1e-15); bits == 0 {
+		t.Errorf("complex128 can't tell apart pixels of 1e-15")
+	}
+}
@@ -0,0 +1,75 @@
+package mandelbrot
+
+// neighborhood are the offsets to the neighbors of a point, the diagonals are included to follow thin filaments.
+var neighborhood = [][2]int{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}}
+
+// calculateBoundaryTracing calculates the points of the area with the BoundaryTracingStrategy.
+// The points of the border of the area are calculated first and every time two neighbors have different iterations,
+// the neighbors of both are calculated too. This follows the edges of the regions with the same iterations, and
+// finally the points that were not calculated are filled with the result of their left neighbor when it is inside the
+// set. The rest are calculated, because escaped points with the same iterations have different smooth iterations and
+// distances, and interior points of the same region may have different periods.
+func (a *Area) calculateBoundaryTracing(params Params) {
+	const (
+		unknown = iota
+		queued
+		calculated
+	)
+	state := make([]uint8, len(a.Points))
+	queue := make([]int, 0, 2*(a.HorizontalResolution+a.VerticalResolution))
+	enqueue := func(x, y int) {
+		if x < 0 || y < 0 || x >= a.HorizontalResolution || y >= a.VerticalResolution {
+			return
+		}
+		i := a.IndexFor(x, y)
+		if state[i] == unknown {
+			state[i] = queued
+			queue = append(queue, i)
+		}
+	}
+	enqueueNeighbors := func(x, y int) {
+		for _, neighbor := range neighborhood {
+			enqueue(x+neighbor[0], y+neighbor[1])
+		}
+	}
+
+	for x := 0; x < a.HorizontalResolution; x++ {
+		enqueue(x, 0)
+		enqueue(x, a.VerticalResolution-1)
+	}
+	for y := 0; y < a.VerticalResolution; y++ {
+		enqueue(0, y)
+		enqueue(a.HorizontalResolution-1, y)
+	}
+
+	for len(queue) > 0 {
+		i := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		a.Points[i].CalculateParams(params)
+		state[i] = calculated
+
+		x, y := a.ForIndex(i)
+		for _, neighbor := range neighborhood {
+			nx, ny := x+neighbor[0], y+neighbor[1]
+			if nx < 0 || ny < 0 || nx >= a.HorizontalResolution || ny >= a.VerticalResolution {
+				continue
+			}
+			n := a.IndexFor(nx, ny)
+			if state[n] == calculated && a.Points[n].iterations != a.Points[i].iterations {
+				enqueueNeighbors(x, y)
+				enqueueNeighbors(nx, ny)
+			}
+		}
+	}
+
+	for i := range a.Points {
+		if state[i] != unknown {
+			continue
+		}
+		if left := &a.Points[i-1]; !params.Interior && !left.escaped {
+			a.Points[i] = left.filledAt(a.Points[i].Point)
+			continue
+		}
+		a.Points[i].CalculateParams(params)
+	}
+}
@@ -0,0 +1,65 @@
+package mandelbrot_test
+
+import (
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+func TestBoundaryTracingMatchesBruteForce(t *testing.T) {
+	tests := []struct {
+		julia      bool
+		c          complex128
+		x, y, size float64
+	}{
+		{false, 0, -0.75, 0, 1.5},
+		{false, 0, -0.1, 0, 0.2},
+		{false, 0, -0.7435669, 0.1314023, 0.001},
+		{false, 0, 0.2925, 0.0147, 0.002},
+		{false, 0, -1.7490863, 0, 0.00001},
+		{false, 0, -0.1011, 0.9563, 0.01},
+		{true, complex(-0.8, 0.156), 0, 0, 1.5},
+		{true, complex(-0.5, 0.5), 0.1, 0.2, 0.1},
+	}
+
+	for i, test := range tests {
+		for _, detailed := range []bool{false, true} {
+			newArea := func(strategy Strategy) *Area {
+				area := NewAreaCentered(120, 1000, test.x, test.y, test.size)
+				area.Julia = test.julia
+				area.C = test.c
+				area.Strategy = strategy
+				area.Distance = detailed
+				area.Interior = detailed
+				area.Init()
+				area.Calculate()
+				return area
+			}
+			expected, got := newArea(BruteForceStrategy), newArea(BoundaryTracingStrategy)
+			for j := range expected.Points {
+				if !sameResult(expected.Points[j], got.Points[j]) {
+					x, y := expected.ForIndex(j)
+					t.Errorf("Test %d failed, point %d,%d expected %+v got %+v", i, x, y, expected.Points[j], got.Points[j])
+				}
+			}
+		}
+	}
+}
+
+func TestBoundaryTracingDeepen(t *testing.T) {
+	oneShot := newStrategyArea(BoundaryTracingStrategy, 2000, -0.75, 0, 1.5)
+	incremental := newStrategyArea(BoundaryTracingStrategy, 50, -0.75, 0, 1.5)
+	incremental.Deepen(500)
+	incremental.Deepen(2000)
+	bruteForce := newStrategyArea(BruteForceStrategy, 2000, -0.75, 0, 1.5)
+	if count := differences(oneShot, incremental); count > 0 {
+		t.Errorf("%d points differ after Deepen", count)
+	}
+	if count := differences(bruteForce, incremental); count > 0 {
+		t.Errorf("%d points differ from brute force after Deepen", count)
+	}
+}
+
+func BenchmarkBoundaryTracingStrategy(b *testing.B) {
+	benchmarkStrategy(b, BoundaryTracingStrategy)
+}
@@ -0,0 +1,346 @@
+package mandelbrot
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// checkpointVersion changes every time the format of the checkpoints changes, old checkpoints can't be loaded.
+const checkpointVersion = 1
+
+// checkpoint is the state of a Picture encoded with encoding/gob by SaveCheckpoint. Only the complete areas are
+// saved, the others are calculated again when the checkpoint is resumed.
+type checkpoint struct {
+	Version int
+	Picture pictureState
+	// Areas has the state of the complete areas by their index.
+	Areas map[int]areaState
+}
+
+// pictureState has the parameters of a Picture, the Formula is saved by its name, see ParseFormula.
+type pictureState struct {
+	TopLeft               complex128
+	ChunkSize             float64
+	Rotation              float64
+	MaxIterations         int
+	Bailout               float64
+	Julia                 bool
+	C                     complex128
+	Formula               string
+	Distance              bool
+	Interior              bool
+	Samples               int
+	Sampling              SamplingPattern
+	AdaptiveDepth         int
+	AdaptiveThreshold     float64
+	Strategy              Strategy
+	DisableSymmetry       bool
+	Precision             uint
+	BigTopLeft            *BigComplex
+	Perturbation          bool
+	HorizontalImageChunks int
+	VerticalImageChunks   int
+	ChunkImageSize        int
+	ChunkImageWidth       int
+	ChunkImageHeight      int
+	ImageWidth            int
+	ImageHeight           int
+	Order                 TileOrder
+	Scheduler             Scheduler
+	Workers               int
+}
+
+type areaState struct {
+	Points    []pointState
+	Samples   [][]pointState
+	BigPoints []bigPointState
+}
+
+// pointState has the result and the orbit of a Point.
+type pointState struct {
+	Point      complex128
+	Iterations int
+	Z          complex128
+	Escaped    bool
+	Smooth     float64
+	Dz         complex128
+	Distance   float64
+	Interior   bool
+	Period     int
+	Saved      complex128
+	SavedAt    int
+	Interval   int
+	Filled     bool
+}
+
+// bigPointState has the result and the orbit of a BigPoint.
+type bigPointState struct {
+	Iterations int
+	Zr, Zi     []byte
+	Escaped    bool
+	Smooth     float64
+	Dz         complex128
+	Distance   float64
+}
+
+// SaveCheckpoint writes the parameters of the picture and the points of its complete areas, with their orbits so
+// they can be deepened, to w. It can be called while the picture is calculated, the areas that are not complete yet
+// are calculated again when the checkpoint is resumed, see LoadCheckpoint.
+func (p *Picture) SaveCheckpoint(w io.Writer) error {
+	state := checkpoint{
+		Version: checkpointVersion,
+		Picture: pictureState{
+			TopLeft:               p.TopLeft,
+			ChunkSize:             p.ChunkSize,
+			Rotation:              p.Rotation,
+			MaxIterations:         p.MaxIterations,
+			Bailout:               p.Bailout,
+			Julia:                 p.Julia,
+			C:                     p.C,
+			Distance:              p.Distance,
+			Interior:              p.Interior,
+			Samples:               p.Samples,
+			Sampling:              p.Sampling,
+			AdaptiveDepth:         p.AdaptiveDepth,
+			AdaptiveThreshold:     p.AdaptiveThreshold,
+			Strategy:              p.Strategy,
+			DisableSymmetry:       p.DisableSymmetry,
+			Precision:             p.Precision,
+			BigTopLeft:            p.BigTopLeft,
+			Perturbation:          p.Perturbation,
+			HorizontalImageChunks: p.HorizontalImageChunks,
+			VerticalImageChunks:   p.VerticalImageChunks,
+			ChunkImageSize:        p.ChunkImageSize,
+			ChunkImageWidth:       p.ChunkImageWidth,
+			ChunkImageHeight:      p.ChunkImageHeight,
+			ImageWidth:            p.ImageWidth,
+			ImageHeight:           p.ImageHeight,
+			Order:                 p.Order,
+			Scheduler:             p.Scheduler,
+			Workers:               p.Workers,
+		},
+		Areas: make(map[int]areaState),
+	}
+	if p.Formula != nil {
+		if _, err := ParseFormula(p.Formula.String()); err != nil {
+			return fmt.Errorf("the formula %s can't be saved, cause: %s", p.Formula, err)
+		}
+		state.Picture.Formula = p.Formula.String()
+	}
+
+	// The areas are not modified once they are complete, so the lock is only needed to check them.
+	p.mutex.Lock()
+	complete := append([]bool(nil), p.complete...)
+	p.mutex.Unlock()
+	for i := range complete {
+		if !complete[i] {
+			continue
+		}
+		area, err := p.areas[i].state()
+		if err != nil {
+			return err
+		}
+		state.Areas[i] = area
+	}
+	return gob.NewEncoder(w).Encode(state)
+}
+
+// LoadCheckpoint reads a checkpoint written by SaveCheckpoint and returns the picture initialized with the points of
+// the complete areas. Resume calculates the rest of the areas. The reference orbit of the perturbation method is not
+// saved, Init calculates it again from the same parameters, so it is the same orbit.
+func LoadCheckpoint(r io.Reader) (*Picture, error) {
+	var state checkpoint
+	if err := gob.NewDecoder(r).Decode(&state); err != nil {
+		return nil, err
+	}
+	if state.Version != checkpointVersion {
+		return nil, fmt.Errorf("the checkpoint version %d is not supported", state.Version)
+	}
+
+	s := state.Picture
+	p := &Picture{
+		TopLeft:               s.TopLeft,
+		ChunkSize:             s.ChunkSize,
+		Rotation:              s.Rotation,
+		MaxIterations:         s.MaxIterations,
+		Bailout:               s.Bailout,
+		Julia:                 s.Julia,
+		C:                     s.C,
+		Distance:              s.Distance,
+		Interior:              s.Interior,
+		Samples:               s.Samples,
+		Sampling:              s.Sampling,
+		AdaptiveDepth:         s.AdaptiveDepth,
+		AdaptiveThreshold:     s.AdaptiveThreshold,
+		Strategy:              s.Strategy,
+		DisableSymmetry:       s.DisableSymmetry,
+		Precision:             s.Precision,
+		BigTopLeft:            s.BigTopLeft,
+		Perturbation:          s.Perturbation,
+		HorizontalImageChunks: s.HorizontalImageChunks,
+		VerticalImageChunks:   s.VerticalImageChunks,
+		ChunkImageSize:        s.ChunkImageSize,
+		ChunkImageWidth:       s.ChunkImageWidth,
+		ChunkImageHeight:      s.ChunkImageHeight,
+		ImageWidth:            s.ImageWidth,
+		ImageHeight:           s.ImageHeight,
+		Order:                 s.Order,
+		Scheduler:             s.Scheduler,
+		Workers:               s.Workers,
+	}
+	if s.Formula != "" {
+		formula, err := ParseFormula(s.Formula)
+		if err != nil {
+			return nil, err
+		}
+		p.Formula = formula
+	}
+	if p.HorizontalImageChunks <= 0 || p.VerticalImageChunks <= 0 || p.chunkImageWidth() <= 0 || p.chunkImageHeight() <= 0 {
+		return nil, fmt.Errorf("the checkpoint has no areas")
+	}
+
+	p.Init()
+	for i, area := range state.Areas {
+		if i < 0 || i >= len(p.areas) {
+			return nil, fmt.Errorf("the checkpoint has the area %d of %d", i, len(p.areas))
+		}
+		if err := p.areas[i].restore(area); err != nil {
+			return nil, fmt.Errorf("the area %d can't be restored, cause: %s", i, err)
+		}
+		p.complete[i] = true
+	}
+	return p, nil
+}
+
+// state returns the state of the points of the area.
+func (a *Area) state() (areaState, error) {
+	state := areaState{Points: make([]pointState, len(a.Points))}
+	for i := range a.Points {
+		state.Points[i] = a.Points[i].state()
+	}
+	if a.samples != nil {
+		state.Samples = make([][]pointState, len(a.samples))
+		for i := range a.samples {
+			state.Samples[i] = make([]pointState, len(a.samples[i]))
+			for j := range a.samples[i] {
+				state.Samples[i][j] = a.samples[i][j].state()
+			}
+		}
+	}
+	if a.bigPoints != nil {
+		state.BigPoints = make([]bigPointState, len(a.bigPoints))
+		for i := range a.bigPoints {
+			point, err := a.bigPoints[i].state()
+			if err != nil {
+				return areaState{}, err
+			}
+			state.BigPoints[i] = point
+		}
+	}
+	return state, nil
+}
+
+// restore replaces the points of an initialized area by the ones in state.
+func (a *Area) restore(state areaState) error {
+	if len(state.Points) != len(a.Points) {
+		return fmt.Errorf("it has %d points instead of %d", len(state.Points), len(a.Points))
+	}
+	if (state.Samples == nil) != (a.samples == nil) || len(state.Samples) != len(a.samples) {
+		return fmt.Errorf("the samples don't match")
+	}
+	if len(state.BigPoints) != len(a.bigPoints) {
+		return fmt.Errorf("it has %d high precision points instead of %d", len(state.BigPoints), len(a.bigPoints))
+	}
+	for i := range state.Points {
+		a.Points[i].restore(state.Points[i])
+	}
+	for i := range state.Samples {
+		a.samples[i] = make([]Point, len(state.Samples[i]))
+		for j := range state.Samples[i] {
+			a.samples[i][j].restore(state.Samples[i][j])
+		}
+	}
+	for i := range state.BigPoints {
+		if err := a.bigPoints[i].restore(state.BigPoints[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Point) state() pointState {
+	return pointState{
+		Point:      m.Point,
+		Iterations: m.iterations,
+		Z:          m.z,
+		Escaped:    m.escaped,
+		Smooth:     m.smooth,
+		Dz:         m.dz,
+		Distance:   m.distance,
+		Interior:   m.interior,
+		Period:     m.period,
+		Saved:      m.saved,
+		SavedAt:    m.savedAt,
+		Interval:   m.interval,
+		Filled:     m.filled,
+	}
+}
+
+func (m *Point) restore(state pointState) {
+	*m = Point{
+		Point:      state.Point,
+		iterations: state.Iterations,
+		z:          state.Z,
+		escaped:    state.Escaped,
+		smooth:     state.Smooth,
+		dz:         state.Dz,
+		distance:   state.Distance,
+		interior:   state.Interior,
+		period:     state.Period,
+		saved:      state.Saved,
+		savedAt:    state.SavedAt,
+		interval:   state.Interval,
+		filled:     state.Filled,
+	}
+}
+
+func (m *BigPoint) state() (bigPointState, error) {
+	state := bigPointState{
+		Iterations: m.iterations,
+		Escaped:    m.escaped,
+		Smooth:     m.smooth,
+		Dz:         m.dz,
+		Distance:   m.distance,
+	}
+	var err error
+	if m.zr != nil {
+		if state.Zr, err = m.zr.GobEncode(); err != nil {
+			return state, err
+		}
+		if state.Zi, err = m.zi.GobEncode(); err != nil {
+			return state, err
+		}
+	}
+	return state, nil
+}
+
+// restore replaces the result and the orbit of the point, the coordinates are kept.
+func (m *BigPoint) restore(state bigPointState) error {
+	m.iterations = state.Iterations
+	m.escaped = state.Escaped
+	m.smooth = state.Smooth
+	m.dz = state.Dz
+	m.distance = state.Distance
+	m.zr, m.zi = nil, nil
+	if state.Zr == nil {
+		return nil
+	}
+	// The precision of the orbit is decoded too.
+	m.zr, m.zi = new(big.Float), new(big.Float)
+	if err := m.zr.GobDecode(state.Zr); err != nil {
+		return err
+	}
+	return m.zi.GobDecode(state.Zi)
+}
@@ -0,0 +1,211 @@
+package mandelbrot_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+func newCheckpointPicture(t *testing.T, maxIterations int, options ...Option) *Picture {
+	options = append([]Option{
+		WithView(View{Center: complex(-0.75, 0.1), Zoom: 4}),
+		WithTileSize(10, 10),
+		WithMaxIterations(maxIterations),
+		WithFormula(Multibrot{Power: 2}),
+	}, options...)
+	pic, err := NewPictureWithOptions(60, 40, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	return pic
+}
+
+func TestCheckpointResume(t *testing.T) {
+	// The resumed areas are calculated instead of mirrored, so the symmetry is disabled to compare them.
+	expected := newCheckpointPicture(t, 300, WithInterior(), WithoutSymmetry())
+	for range expected.CalculateAsync(context.Background(), 2) {
+	}
+
+	pic := newCheckpointPicture(t, 300, WithInterior(), WithoutSymmetry())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := 0
+	for range pic.CalculateAsync(ctx, 2) {
+		done++
+		if done == 5 {
+			cancel()
+		}
+	}
+	buffer := &bytes.Buffer{}
+	if err := pic.SaveCheckpoint(buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadCheckpoint(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Formula.String() != "mandelbrot" || loaded.MaxIterations != 300 || !loaded.Interior || loaded.ImageWidth != 60 {
+		t.Errorf("Parameters not restored, got %+v", loaded)
+	}
+	if len(loaded.Missing()) != len(pic.Missing()) {
+		t.Errorf("Expected %d missing areas, got %d", len(pic.Missing()), len(loaded.Missing()))
+	}
+	for range loaded.ResumeAsync(context.Background(), 2) {
+	}
+	assertSamePoints(t, expected, loaded)
+}
+
+func TestCheckpointDeepen(t *testing.T) {
+	expected := newCheckpointPicture(t, 2000)
+	for range expected.CalculateAsync(context.Background(), 2) {
+	}
+
+	pic := newCheckpointPicture(t, 100)
+	for range pic.CalculateAsync(context.Background(), 2) {
+	}
+	buffer := &bytes.Buffer{}
+	if err := pic.SaveCheckpoint(buffer); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCheckpoint(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := loaded.Missing(); len(missing) != 0 {
+		t.Fatalf("Expected a complete picture, missing %v", missing)
+	}
+
+	// The orbits are restored, so the points continue where they stopped.
+	done := make(chan int)
+	go loaded.Deepen(context.Background(), 2000, 2, done)
+	for range done {
+	}
+	assertSamePoints(t, expected, loaded)
+}
+
+func TestCheckpointPrecision(t *testing.T) {
+	newPicture := func() *Picture {
+		pic := newCheckpointPicture(t, 100, WithPrecision(80), WithoutSymmetry())
+		return pic
+	}
+	expected := newPicture()
+	for range expected.CalculateAsync(context.Background(), 2) {
+	}
+
+	pic := newPicture()
+	pic.MaxIterations = 50
+	pic.Init()
+	for range pic.CalculateAsync(context.Background(), 2) {
+	}
+	buffer := &bytes.Buffer{}
+	if err := pic.SaveCheckpoint(buffer); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCheckpoint(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan int)
+	go loaded.Deepen(context.Background(), 100, 2, done)
+	for range done {
+	}
+	assertSamePoints(t, expected, loaded)
+}
+
+func TestCheckpointPerturbation(t *testing.T) {
+	newPicture := func(maxIterations int) *Picture {
+		return newCheckpointPicture(t, maxIterations, WithPrecision(80), WithPerturbation(), WithoutSymmetry())
+	}
+	expected := newPicture(100)
+	for range expected.CalculateAsync(context.Background(), 2) {
+	}
+
+	// A cancelled calculation is resumed with the reference orbit calculated again by LoadCheckpoint.
+	pic := newPicture(100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := 0
+	for range pic.CalculateAsync(ctx, 1) {
+		done++
+		if done == 5 {
+			cancel()
+		}
+	}
+	buffer := &bytes.Buffer{}
+	if err := pic.SaveCheckpoint(buffer); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCheckpoint(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Missing()) == 0 {
+		t.Fatal("Expected missing areas")
+	}
+	for range loaded.ResumeAsync(context.Background(), 2) {
+	}
+	assertSamePoints(t, expected, loaded)
+
+	// The reference orbit is extended when the loaded picture is deepened.
+	shallow := newPicture(50)
+	for range shallow.CalculateAsync(context.Background(), 2) {
+	}
+	buffer.Reset()
+	if err := shallow.SaveCheckpoint(buffer); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = LoadCheckpoint(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doneIndex := make(chan int)
+	go loaded.Deepen(context.Background(), 100, 2, doneIndex)
+	for range doneIndex {
+	}
+	assertSamePoints(t, expected, loaded)
+}
+
+func TestCheckpointWhileCalculating(t *testing.T) {
+	expected := newCheckpointPicture(t, 1000)
+	for range expected.CalculateAsync(context.Background(), 2) {
+	}
+
+	pic := newCheckpointPicture(t, 1000)
+	done := pic.CalculateAsync(context.Background(), 2)
+	for range done {
+		buffer := &bytes.Buffer{}
+		if err := pic.SaveCheckpoint(buffer); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadCheckpoint(buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < loaded.HorizontalImageChunks*loaded.VerticalImageChunks; i++ {
+			if !loaded.Complete(i) {
+				continue
+			}
+			expectedArea, loadedArea := expected.GetArea(i), loaded.GetArea(i)
+			for j := range expectedArea.Points {
+				if expectedArea.Points[j] != loadedArea.Points[j] {
+					t.Fatalf("Complete area %d point %d differs", i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestCheckpointUnknownFormula(t *testing.T) {
+	var iterations int64
+	pic := newCheckpointPicture(t, 10, WithFormula(countingFormula{iterations: &iterations}))
+	if err := pic.SaveCheckpoint(&bytes.Buffer{}); err == nil {
+		t.Error("Expected an error for a formula that can't be parsed")
+	}
+	if _, err := LoadCheckpoint(bytes.NewBufferString("not a checkpoint")); err == nil {
+		t.Error("Expected an error for an invalid checkpoint")
+	}
+}
@@ -0,0 +1,462 @@
+package mandelbrot
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression is a Formula defined by a text like "z^3 - z + c" or "sin(z)*c".
+//
+// The variables are z, the current value of the orbit, and c, the parameter of the point. The constants i, pi and e
+// are also available, numbers can be written with an i suffix to make them imaginary, for example 0.5i.
+// The operators are + - * / and ^ (power) with the usual precedence. The functions are pow(a, b), exp, log, sqrt, sin,
+// cos, tan, sinh, cosh, abs, conj, re and im, all of them working on complex numbers.
+type Expression struct {
+	source string
+	root   node
+}
+
+// ParseError describes why an expression can't be parsed.
+type ParseError struct {
+	Expression string
+	// Position is the offset in bytes of the problem in Expression.
+	Position int
+	Message  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid formula %q at position %d: %s", e.Expression, e.Position, e.Message)
+}
+
+// ParseExpression compiles the text into an Expression, the returned error is a *ParseError.
+func ParseExpression(source string) (*Expression, error) {
+	p := &parser{source: source}
+	p.next()
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.token.kind != tokenEnd {
+		return nil, p.errorf("unexpected %s", p.token)
+	}
+	return &Expression{source: strings.TrimSpace(source), root: root}, nil
+}
+
+// ParseFormula returns the built in formula with the given name or, if there is none, the compiled expression.
+func ParseFormula(text string) (Formula, error) {
+	formula, err := FormulaByName(text)
+	if err == nil {
+		return formula, nil
+	}
+	if strings.HasPrefix(text, "multibrot") {
+		return nil, err
+	}
+	return ParseExpression(text)
+}
+
+// Iterate evaluates the expression.
+func (e *Expression) Iterate(z, c complex128) complex128 {
+	return e.root.eval(z, c)
+}
+
+// Degree is the power of z for polynomial expressions. It is 2 for other expressions, where the smooth iterations are
+// just an approximation.
+func (e *Expression) Degree() float64 {
+	if !e.root.polynomial || e.root.degree < 2 {
+		return 2
+	}
+	return e.root.degree
+}
+
+// ConjugateSymmetric reports whether the expression only uses real constants and functions that commute with
+// conjugation, all of them except im.
+func (e *Expression) ConjugateSymmetric() bool {
+	return !e.root.asymmetric
+}
+
+// String returns the text of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// node is a compiled part of an expression.
+type node struct {
+	eval func(z, c complex128) complex128
+	// constant nodes do not depend on z or c and they are evaluated only once while parsing.
+	constant bool
+	// polynomial nodes are polynomials of z, degree is the highest power of z.
+	polynomial bool
+	degree     float64
+	// asymmetric nodes do not commute with conjugation, see ConjugateSymmetric.
+	asymmetric bool
+}
+
+func constantNode(value complex128) node {
+	return node{
+		eval:       func(z, c complex128) complex128 { return value },
+		constant:   true,
+		polynomial: true,
+		asymmetric: imag(value) != 0,
+	}
+}
+
+// fold replaces the node by its value if it is constant.
+func fold(n node) node {
+	if !n.constant {
+		return n
+	}
+	return constantNode(n.eval(0, 0))
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenImaginary
+	tokenIdentifier
+	tokenOperator
+	tokenInvalid
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEnd {
+		return "end of formula"
+	}
+	return strconv.Quote(t.text)
+}
+
+type parser struct {
+	source string
+	offset int
+	token  token
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &ParseError{
+		Expression: p.source,
+		Position:   p.token.position,
+		Message:    fmt.Sprintf(format, args...),
+	}
+}
+
+// next moves to the following token.
+func (p *parser) next() {
+	for p.offset < len(p.source) && unicode.IsSpace(rune(p.source[p.offset])) {
+		p.offset++
+	}
+	start := p.offset
+	if p.offset == len(p.source) {
+		p.token = token{kind: tokenEnd, position: start}
+		return
+	}
+
+	char := p.source[p.offset]
+	switch {
+	case isDigit(char) || char == '.':
+		p.offset++
+		for p.offset < len(p.source) && (isDigit(p.source[p.offset]) || p.source[p.offset] == '.') {
+			p.offset++
+		}
+		if p.offset+1 < len(p.source) && (p.source[p.offset] == 'e' || p.source[p.offset] == 'E') {
+			exponent := p.offset + 1
+			if p.source[exponent] == '+' || p.source[exponent] == '-' {
+				exponent++
+			}
+			if exponent < len(p.source) && isDigit(p.source[exponent]) {
+				p.offset = exponent
+				for p.offset < len(p.source) && isDigit(p.source[p.offset]) {
+					p.offset++
+				}
+			}
+		}
+		kind := tokenNumber
+		if p.offset < len(p.source) && p.source[p.offset] == 'i' && (p.offset+1 == len(p.source) || !isLetter(p.source[p.offset+1])) {
+			p.offset++
+			kind = tokenImaginary
+		}
+		p.token = token{kind: kind, text: p.source[start:p.offset], position: start}
+	case isLetter(char):
+		for p.offset < len(p.source) && (isLetter(p.source[p.offset]) || isDigit(p.source[p.offset])) {
+			p.offset++
+		}
+		p.token = token{kind: tokenIdentifier, text: p.source[start:p.offset], position: start}
+	case strings.IndexByte("+-*/^(),", char) >= 0:
+		p.offset++
+		p.token = token{kind: tokenOperator, text: p.source[start:p.offset], position: start}
+	default:
+		p.offset++
+		p.token = token{kind: tokenInvalid, text: p.source[start:p.offset], position: start}
+	}
+}
+
+func (p *parser) isOperator(operator string) bool {
+	return p.token.kind == tokenOperator && p.token.text == operator
+}
+
+// expect consumes the given operator or fails.
+func (p *parser) expect(operator string) error {
+	if !p.isOperator(operator) {
+		return p.errorf("expected %q but found %s", operator, p.token)
+	}
+	p.next()
+	return nil
+}
+
+// parseSum parses terms separated by + and -.
+func (p *parser) parseSum() (node, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return node{}, err
+	}
+	for p.isOperator("+") || p.isOperator("-") {
+		operator := p.token.text
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return node{}, err
+		}
+		a, b := left.eval, right.eval
+		result := node{
+			constant:   left.constant && right.constant,
+			polynomial: left.polynomial && right.polynomial,
+			degree:     math.Max(left.degree, right.degree),
+			asymmetric: left.asymmetric || right.asymmetric,
+		}
+		if operator == "+" {
+			result.eval = func(z, c complex128) complex128 { return a(z, c) + b(z, c) }
+		} else {
+			result.eval = func(z, c complex128) complex128 { return a(z, c) - b(z, c) }
+		}
+		left = fold(result)
+	}
+	return left, nil
+}
+
+// parseProduct parses factors separated by * and /.
+func (p *parser) parseProduct() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return node{}, err
+	}
+	for p.isOperator("*") || p.isOperator("/") {
+		operator := p.token.text
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return node{}, err
+		}
+		a, b := left.eval, right.eval
+		result := node{
+			constant:   left.constant && right.constant,
+			asymmetric: left.asymmetric || right.asymmetric,
+		}
+		if operator == "*" {
+			result.eval = func(z, c complex128) complex128 { return a(z, c) * b(z, c) }
+			result.polynomial = left.polynomial && right.polynomial
+			result.degree = left.degree + right.degree
+		} else {
+			result.eval = func(z, c complex128) complex128 { return a(z, c) / b(z, c) }
+			result.polynomial = left.polynomial && right.constant
+			result.degree = left.degree
+		}
+		left = fold(result)
+	}
+	return left, nil
+}
+
+// parseUnary parses a power with an optional sign.
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator("-") || p.isOperator("+") {
+		negative := p.isOperator("-")
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return node{}, err
+		}
+		if !negative {
+			return operand, nil
+		}
+		a := operand.eval
+		operand.eval = func(z, c complex128) complex128 { return -a(z, c) }
+		return fold(operand), nil
+	}
+	return p.parsePower()
+}
+
+// parsePower parses a primary optionally raised to an exponent, the power is right associative.
+func (p *parser) parsePower() (node, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return node{}, err
+	}
+	if !p.isOperator("^") {
+		return base, nil
+	}
+	p.next()
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return node{}, err
+	}
+	return power(base, exponent), nil
+}
+
+// power returns base^exponent, integer constant exponents are calculated with multiplications which is faster and exact.
+func power(base, exponent node) node {
+	a, b := base.eval, exponent.eval
+	result := node{
+		constant:   base.constant && exponent.constant,
+		eval:       func(z, c complex128) complex128 { return cmplx.Pow(a(z, c), b(z, c)) },
+		asymmetric: base.asymmetric || exponent.asymmetric,
+	}
+	if exponent.constant {
+		value := exponent.eval(0, 0)
+		n := real(value)
+		if imag(value) == 0 && n >= 0 && n == math.Trunc(n) && n <= 64 {
+			times := int(n)
+			result.eval = func(z, c complex128) complex128 {
+				x := a(z, c)
+				result := complex(1, 0)
+				for i := 0; i < times; i++ {
+					result *= x
+				}
+				return result
+			}
+			result.polynomial = base.polynomial
+			result.degree = base.degree * n
+		}
+	}
+	return fold(result)
+}
+
+// functions are the functions of one argument available in expressions, pow is handled apart because it has two.
+var functions = map[string]func(complex128) complex128{
+	"exp":  cmplx.Exp,
+	"log":  cmplx.Log,
+	"sqrt": cmplx.Sqrt,
+	"sin":  cmplx.Sin,
+	"cos":  cmplx.Cos,
+	"tan":  cmplx.Tan,
+	"sinh": cmplx.Sinh,
+	"cosh": cmplx.Cosh,
+	"abs":  func(x complex128) complex128 { return complex(cmplx.Abs(x), 0) },
+	"conj": cmplx.Conj,
+	"re":   func(x complex128) complex128 { return complex(real(x), 0) },
+	"im":   func(x complex128) complex128 { return complex(imag(x), 0) },
+}
+
+// sameGrowth are the functions that grow as fast as their argument, so they keep the degree of polynomials.
+var sameGrowth = map[string]bool{"abs": true, "conj": true, "re": true, "im": true}
+
+// parsePrimary parses numbers, variables, function calls and parenthesis.
+func (p *parser) parsePrimary() (node, error) {
+	current := p.token
+	switch current.kind {
+	case tokenNumber, tokenImaginary:
+		text := strings.TrimSuffix(current.text, "i")
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return node{}, p.errorf("invalid number %s", current)
+		}
+		p.next()
+		if current.kind == tokenImaginary {
+			return constantNode(complex(0, value)), nil
+		}
+		return constantNode(complex(value, 0)), nil
+	case tokenIdentifier:
+		p.next()
+		if p.isOperator("(") {
+			return p.parseCall(current)
+		}
+		switch current.text {
+		case "z":
+			return node{eval: func(z, c complex128) complex128 { return z }, polynomial: true, degree: 1}, nil
+		case "c":
+			return node{eval: func(z, c complex128) complex128 { return c }, polynomial: true}, nil
+		case "i":
+			return constantNode(complex(0, 1)), nil
+		case "pi":
+			return constantNode(complex(math.Pi, 0)), nil
+		case "e":
+			return constantNode(complex(math.E, 0)), nil
+		}
+		p.token = current
+		return node{}, p.errorf("unknown variable %s, use z, c, i, pi or e", current)
+	case tokenOperator:
+		if current.text == "(" {
+			p.next()
+			inner, err := p.parseSum()
+			if err != nil {
+				return node{}, err
+			}
+			return inner, p.expect(")")
+		}
+	}
+	return node{}, p.errorf("unexpected %s", current)
+}
+
+// parseCall parses the arguments of the function named by the given token.
+func (p *parser) parseCall(name token) (node, error) {
+	function, ok := functions[name.text]
+	arguments := 1
+	if name.text == "pow" {
+		ok, arguments = true, 2
+	}
+	if !ok {
+		p.token = name
+		return node{}, p.errorf("unknown function %s", name)
+	}
+	p.next()
+
+	var args []node
+	for !p.isOperator(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return node{}, err
+			}
+		}
+		arg, err := p.parseSum()
+		if err != nil {
+			return node{}, err
+		}
+		args = append(args, arg)
+	}
+	if len(args) != arguments {
+		return node{}, p.errorf("%s expects %d arguments but got %d", name.text, arguments, len(args))
+	}
+	p.next()
+
+	if name.text == "pow" {
+		return power(args[0], args[1]), nil
+	}
+
+	arg := args[0].eval
+	result := node{
+		eval: func(z, c complex128) complex128 {
+			return function(arg(z, c))
+		},
+		constant:   args[0].constant,
+		polynomial: args[0].constant || (sameGrowth[name.text] && args[0].polynomial),
+		degree:     args[0].degree,
+		asymmetric: args[0].asymmetric || name.text == "im",
+	}
+	return fold(result), nil
+}
+
+func isDigit(char byte) bool {
+	return '0' <= char && char <= '9'
+}
+
+func isLetter(char byte) bool {
+	return 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z' || char == '_'
+}
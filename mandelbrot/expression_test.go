@@ -0,0 +1,152 @@
+package mandelbrot_test
+
+import (
+	"math/cmplx"
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+func TestExpressionIterate(t *testing.T) {
+	z, c := complex(0.5, -1), complex(-0.3, 0.2)
+	tests := []struct {
+		source   string
+		expected complex128
+	}{
+		{"z^2 + c", z*z + c},
+		{"z*z+c", z*z + c},
+		{"z^3 - z + c", z*z*z - z + c},
+		{"sin(z)*c", cmplx.Sin(z) * c},
+		{"-z^2", -(z * z)},
+		{"2^-1 * z", 0.5 * z},
+		{"2^3^2", 512},
+		{"pow(z, 2.5) + c", cmplx.Pow(z, 2.5) + c},
+		{"exp(z) - cos(c) / 2", cmplx.Exp(z) - cmplx.Cos(c)/2},
+		{"conj(z)^2 + c", cmplx.Conj(z)*cmplx.Conj(z) + c},
+		{"abs(z) + 0.5i", complex(cmplx.Abs(z), 0.5)},
+		{"(1 + 2i) * z", complex(1, 2) * z},
+		{"1e-1 * i", complex(0, 0.1)},
+	}
+
+	for _, test := range tests {
+		expression, err := ParseExpression(test.source)
+		if err != nil {
+			t.Errorf("%s can't be parsed, cause: %s", test.source, err)
+			continue
+		}
+		if got := expression.Iterate(z, c); cmplx.Abs(got-test.expected) > 1e-12 {
+			t.Errorf("%s expected %v, got %v", test.source, test.expected, got)
+		}
+	}
+}
+
+func TestExpressionDegree(t *testing.T) {
+	tests := []struct {
+		source string
+		degree float64
+	}{
+		{"z^2 + c", 2},
+		{"z^3 - z + c", 3},
+		{"z*z*z*z + c", 4},
+		{"(z^2 + c)^2 / 2", 4},
+		{"conj(z)^2 + c", 2},
+		{"sin(z) * c", 2},
+		{"z + c", 2},
+	}
+
+	for _, test := range tests {
+		expression, err := ParseExpression(test.source)
+		if err != nil {
+			t.Errorf("%s can't be parsed, cause: %s", test.source, err)
+			continue
+		}
+		if got := expression.Degree(); got != test.degree {
+			t.Errorf("%s expected degree %f, got %f", test.source, test.degree, got)
+		}
+	}
+}
+
+func TestExpressionConjugateSymmetric(t *testing.T) {
+	tests := []struct {
+		source    string
+		symmetric bool
+	}{
+		{"z^2 + c", true},
+		{"sin(z) * c", true},
+		{"conj(z)^2 + abs(z) + c", true},
+		{"i*i*z + c", true},
+		{"z^2 + c + i", false},
+		{"im(z) + c", false},
+		{"pow(z, 2i) + c", false},
+	}
+
+	for _, test := range tests {
+		expression, err := ParseExpression(test.source)
+		if err != nil {
+			t.Errorf("%s can't be parsed, cause: %s", test.source, err)
+			continue
+		}
+		if got := expression.ConjugateSymmetric(); got != test.symmetric {
+			t.Errorf("%s expected symmetric %t, got %t", test.source, test.symmetric, got)
+		}
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	tests := []struct {
+		source   string
+		position int
+	}{
+		{"", 0},
+		{"z^", 2},
+		{"z +* c", 3},
+		{"(z + c", 6},
+		{"z + x", 4},
+		{"foo(z)", 0},
+		{"sin(z, c)", 8},
+		{"z $ c", 2},
+		{"z c", 2},
+	}
+
+	for _, test := range tests {
+		_, err := ParseExpression(test.source)
+		parseError, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("%q expected a ParseError, got %v", test.source, err)
+			continue
+		}
+		if parseError.Position != test.position {
+			t.Errorf("%q expected error at %d, got %s", test.source, test.position, parseError)
+		}
+	}
+}
+
+func TestParseFormula(t *testing.T) {
+	formula, err := ParseFormula("burningship")
+	if err != nil || formula != (BurningShip{}) {
+		t.Errorf("built in formulas must be found by name, got %v %v", formula, err)
+	}
+	formula, err = ParseFormula("z^3 + c")
+	if err != nil || formula.String() != "z^3 + c" {
+		t.Errorf("expressions must be parsed, got %v %v", formula, err)
+	}
+	if _, err = ParseFormula("multibrot1"); err == nil {
+		t.Errorf("invalid multibrot formulas must be rejected")
+	}
+}
+
+func TestExpressionMatchesDefault(t *testing.T) {
+	expression, err := ParseExpression("z^2 + c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []complex128{complex(1, 0), complex(-1, 0), complex(-0.5, 0.5), complex(0.3, 0.5), complex(-0.75, 0.1)} {
+		expected := NewPoint(real(c), imag(c))
+		expected.Calculate(1000)
+		got := NewPoint(real(c), imag(c))
+		got.CalculateParams(Params{MaxIterations: 1000, Formula: expression})
+		if expected != got {
+			t.Errorf("Point %f expected %d iterations, got %d", c, expected.Iterations(), got.Iterations())
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package mandelbrot
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Formula is the function iterated for each point. The classic Mandelbrot set is calculated when no Formula is given.
+type Formula interface {
+	// Iterate returns the next value of the orbit z for the parameter c.
+	Iterate(z, c complex128) complex128
+	// Degree is the growth rate of escaped orbits, it is used to calculate smooth iterations.
+	Degree() float64
+	// String returns the name of the formula that FormulaByName understands.
+	String() string
+}
+
+// Differentiable formulas can calculate the distance estimation.
+// They must have the form f(z) + c, so the derivative of the orbit with respect to c is f'(z)*dz + 1.
+type Differentiable interface {
+	// Derivative returns the derivative of Iterate with respect to z.
+	Derivative(z, c complex128) complex128
+}
+
+// ConjugateSymmetric formulas can report that they commute with conjugation, f(conj(z), conj(c)) = conj(f(z, c)),
+// so their sets are symmetric across the real axis.
+type ConjugateSymmetric interface {
+	ConjugateSymmetric() bool
+}
+
+// Multibrot iterates z^Power + c, a Power of 2 is the Mandelbrot set.
+type Multibrot struct {
+	Power int
+}
+
+// Iterate returns z^Power + c.
+func (f Multibrot) Iterate(z, c complex128) complex128 {
+	result := z
+	for i := 1; i < f.Power; i++ {
+		result *= z
+	}
+	return result + c
+}
+
+// Derivative returns Power * z^(Power-1).
+func (f Multibrot) Derivative(z, c complex128) complex128 {
+	result := complex(float64(f.Power), 0)
+	for i := 1; i < f.Power; i++ {
+		result *= z
+	}
+	return result
+}
+
+// ConjugateSymmetric returns true.
+func (f Multibrot) ConjugateSymmetric() bool {
+	return true
+}
+
+// Degree returns Power.
+func (f Multibrot) Degree() float64 {
+	return float64(f.Power)
+}
+
+func (f Multibrot) String() string {
+	if f.Power == 2 {
+		return "mandelbrot"
+	}
+	return "multibrot" + strconv.Itoa(f.Power)
+}
+
+// BurningShip iterates (|Re(z)| + i|Im(z)|)^2 + c.
+type BurningShip struct{}
+
+// Iterate returns (|Re(z)| + i|Im(z)|)^2 + c.
+func (BurningShip) Iterate(z, c complex128) complex128 {
+	z = complex(math.Abs(real(z)), math.Abs(imag(z)))
+	return z*z + c
+}
+
+// Degree returns 2.
+func (BurningShip) Degree() float64 {
+	return 2
+}
+
+func (BurningShip) String() string {
+	return "burningship"
+}
+
+// Tricorn iterates conj(z)^2 + c, it is also known as Mandelbar.
+type Tricorn struct{}
+
+// Iterate returns conj(z)^2 + c.
+func (Tricorn) Iterate(z, c complex128) complex128 {
+	z = complex(real(z), -imag(z))
+	return z*z + c
+}
+
+// Degree returns 2.
+func (Tricorn) Degree() float64 {
+	return 2
+}
+
+// ConjugateSymmetric returns true.
+func (Tricorn) ConjugateSymmetric() bool {
+	return true
+}
+
+func (Tricorn) String() string {
+	return "tricorn"
+}
+
+// Celtic iterates |Re(z^2)| + i*Im(z^2) + c.
+type Celtic struct{}
+
+// Iterate returns |Re(z^2)| + i*Im(z^2) + c.
+func (Celtic) Iterate(z, c complex128) complex128 {
+	z = z * z
+	return complex(math.Abs(real(z)), imag(z)) + c
+}
+
+// Degree returns 2.
+func (Celtic) Degree() float64 {
+	return 2
+}
+
+// ConjugateSymmetric returns true.
+func (Celtic) ConjugateSymmetric() bool {
+	return true
+}
+
+func (Celtic) String() string {
+	return "celtic"
+}
+
+// FormulaByName returns the built in formula with the given name, it is the opposite of Formula.String.
+// Multibrot formulas are named multibrot followed by the power, for example multibrot3.
+func FormulaByName(name string) (Formula, error) {
+	switch name {
+	case "mandelbrot":
+		return Multibrot{Power: 2}, nil
+	case "burningship":
+		return BurningShip{}, nil
+	case "tricorn", "mandelbar":
+		return Tricorn{}, nil
+	case "celtic":
+		return Celtic{}, nil
+	}
+	if strings.HasPrefix(name, "multibrot") {
+		power, err := strconv.Atoi(strings.TrimPrefix(name, "multibrot"))
+		if err != nil || power < 2 {
+			return nil, fmt.Errorf("invalid multibrot power in %s, it must be an integer greater than 1", name)
+		}
+		return Multibrot{Power: power}, nil
+	}
+	return nil, fmt.Errorf("unknown formula %s", name)
+}
+
+// isMandelbrot reports whether f is the classic Mandelbrot formula, which has a faster implementation.
+func isMandelbrot(f Formula) bool {
+	if f == nil {
+		return true
+	}
+	multibrot, ok := f.(Multibrot)
+	return ok && multibrot.Power == 2
+}
@@ -0,0 +1,102 @@
+package mandelbrot_test
+
+import (
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+func TestFormulaIterate(t *testing.T) {
+	tests := []struct {
+		formula  Formula
+		z        complex128
+		c        complex128
+		expected complex128
+	}{
+		{Multibrot{Power: 2}, complex(1, 2), complex(0.5, 0), complex(-2.5, 4)},
+		{Multibrot{Power: 3}, complex(1, 1), 0, complex(-2, 2)},
+		{BurningShip{}, complex(-1, -2), complex(0.5, 0), complex(-2.5, 4)},
+		{Tricorn{}, complex(1, 2), 0, complex(-3, -4)},
+		{Celtic{}, complex(1, 2), complex(0, 1), complex(3, 5)},
+	}
+
+	for _, test := range tests {
+		if got := test.formula.Iterate(test.z, test.c); got != test.expected {
+			t.Errorf("%s(%v, %v) expected %v, got %v", test.formula, test.z, test.c, test.expected, got)
+		}
+	}
+}
+
+func TestFormulaByName(t *testing.T) {
+	for _, formula := range []Formula{Multibrot{Power: 2}, Multibrot{Power: 5}, BurningShip{}, Tricorn{}, Celtic{}} {
+		got, err := FormulaByName(formula.String())
+		if err != nil {
+			t.Errorf("%s can't be found by name, cause: %s", formula, err)
+			continue
+		}
+		if got != formula {
+			t.Errorf("%s found %s", formula, got)
+		}
+	}
+
+	for _, name := range []string{"", "multibrot", "multibrot1", "multibrotx", "unknown"} {
+		if _, err := FormulaByName(name); err == nil {
+			t.Errorf("%s must be rejected", name)
+		}
+	}
+}
+
+func TestFormulaPoint(t *testing.T) {
+	tests := []struct {
+		formula  Formula
+		point    Point
+		diverges bool
+	}{
+		{Multibrot{Power: 3}, NewPoint(0, 0.5), false},
+		{Multibrot{Power: 3}, NewPoint(-1, 0), true},
+		{BurningShip{}, NewPoint(-1.75, -0.02), false},
+		{BurningShip{}, NewPoint(0.5, 0.5), true},
+		{Tricorn{}, NewPoint(-0.2, 0), false},
+		{Tricorn{}, NewPoint(0.5, 0.5), true},
+		{Celtic{}, NewPoint(-0.5, 0), false},
+		{Celtic{}, NewPoint(1, 0), true},
+	}
+
+	for i, test := range tests {
+		test.point.CalculateParams(Params{MaxIterations: 200, Formula: test.formula})
+		if test.point.Escaped() != test.diverges {
+			t.Errorf("Test %d failed, %s Point %f diverges %t expected %t", i, test.formula, test.point.Point, test.point.Escaped(), test.diverges)
+		}
+	}
+}
+
+// square is the Mandelbrot formula implemented outside of the package, it is not detected as Mandelbrot.
+type square struct{}
+
+func (square) Iterate(z, c complex128) complex128 { return z*z + c }
+func (square) Degree() float64                    { return 2 }
+func (square) String() string                     { return "square" }
+
+func TestCustomFormulaMatchesDefault(t *testing.T) {
+	newArea := func(formula Formula) *Area {
+		area := &Area{
+			HorizontalResolution: 40,
+			VerticalResolution:   40,
+			MaxIterations:        200,
+			Formula:              formula,
+			TopLeft:              complex(-2, 1.5),
+			BottomRight:          complex(1, -1.5),
+		}
+		area.Init()
+		area.Calculate()
+		return area
+	}
+
+	expected := newArea(nil)
+	got := newArea(square{})
+	for i := range expected.Points {
+		if expected.Points[i] != got.Points[i] {
+			t.Errorf("Point %d differs, expected %d got %d", i, expected.Points[i].Iterations(), got.Points[i].Iterations())
+		}
+	}
+}
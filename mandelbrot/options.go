@@ -0,0 +1,267 @@
+package mandelbrot
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// defaultTileSize is the size in pixels of the areas of a picture built with options, see WithTileSize.
+	defaultTileSize = 64
+	// defaultMaxIterations is the MaxIterations of a picture built with options, see WithMaxIterations.
+	defaultMaxIterations = 100
+)
+
+// defaultView is the region of a picture built with options, it shows the whole Mandelbrot set.
+var defaultView = View{Center: complex(-0.6, 0)}
+
+// Option configures a picture built with NewPictureWithOptions.
+type Option func(*pictureOptions) error
+
+// pictureOptions holds the configuration that is validated before building the picture.
+type pictureOptions struct {
+	view View
+	// region replaces the view when it is set by WithRegion.
+	region     bool
+	topLeft    complex128
+	width      float64
+	tileWidth  int
+	tileHeight int
+	// picture has the settings of the picture, it is placed and split in areas once all the options are applied.
+	picture *Picture
+}
+
+// NewPictureWithOptions returns a picture of imageWidth by imageHeight pixels configured by the options. By default it
+// shows the whole Mandelbrot set in areas of 64x64 pixels with 100 iterations. All the values are validated before
+// building the picture, a *ParameterError is returned if any of them is invalid.
+func NewPictureWithOptions(imageWidth, imageHeight int, options ...Option) (*Picture, error) {
+	config := pictureOptions{
+		view:    defaultView,
+		picture: &Picture{MaxIterations: defaultMaxIterations},
+	}
+	for _, option := range options {
+		if err := option(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	pic := config.picture
+	switch {
+	case imageWidth <= 0:
+		return nil, &ParameterError{"image width", imageWidth, "it must be positive"}
+	case imageHeight <= 0:
+		return nil, &ParameterError{"image height", imageHeight, "it must be positive"}
+	case config.tileWidth > imageWidth:
+		return nil, &ParameterError{"tile width", config.tileWidth, fmt.Sprintf("it can't be larger than the image width %d", imageWidth)}
+	case config.tileHeight > imageHeight:
+		return nil, &ParameterError{"tile height", config.tileHeight, fmt.Sprintf("it can't be larger than the image height %d", imageHeight)}
+	case pic.Perturbation && pic.Precision == 0:
+		return nil, &ParameterError{"perturbation", pic.Perturbation, "it requires a precision"}
+	}
+	if _, ok := pic.Formula.(Differentiable); pic.Distance && pic.Formula != nil && !ok {
+		return nil, &ParameterError{"formula", pic.Formula, "it can't calculate the distance estimation"}
+	}
+
+	tileWidth, tileHeight := config.tileWidth, config.tileHeight
+	if tileWidth == 0 {
+		tileWidth = minInt(defaultTileSize, imageWidth)
+	}
+	if tileHeight == 0 {
+		tileHeight = minInt(defaultTileSize, imageHeight)
+	}
+
+	if config.region {
+		pic.tile(config.topLeft, config.width, imageWidth, imageHeight, tileWidth, tileHeight)
+		return pic, nil
+	}
+	// The view keeps the pixels square, so its aspect follows the image.
+	view := config.view
+	view.Aspect = float64(imageWidth) / float64(imageHeight)
+	pic.tile(view.TopLeft(), view.Width(), imageWidth, imageHeight, tileWidth, tileHeight)
+	pic.BigTopLeft = view.BigTopLeft()
+	pic.Rotation = view.Rotation
+	return pic, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// set returns an option that applies setting to the configuration if err is nil.
+func set(err error, setting func(o *pictureOptions)) Option {
+	return func(o *pictureOptions) error {
+		if err != nil {
+			return err
+		}
+		setting(o)
+		return nil
+	}
+}
+
+// WithView sets the region of the complex plane shown by the picture. The aspect of the view is ignored, it is taken
+// from the size of the image.
+func WithView(view View) Option {
+	var err error
+	if view.Zoom < 0 || math.IsInf(view.Zoom, 0) || math.IsNaN(view.Zoom) {
+		err = &ParameterError{"zoom", view.Zoom, "it must be a positive number"}
+	}
+	return set(err, func(o *pictureOptions) {
+		o.view = view
+		o.region = false
+	})
+}
+
+// WithRegion sets the region of the complex plane shown by the picture by its top left corner and its width.
+func WithRegion(topLeft complex128, width float64) Option {
+	var err error
+	if width <= 0 || math.IsInf(width, 0) || math.IsNaN(width) {
+		err = &ParameterError{"area width", width, "it must be a positive number"}
+	}
+	return set(err, func(o *pictureOptions) { o.region, o.topLeft, o.width = true, topLeft, width })
+}
+
+// WithTileSize sets the size in pixels of the areas that are calculated independently. The areas at the right and
+// bottom edges are smaller when the image is not a multiple of the tile.
+func WithTileSize(width, height int) Option {
+	var err error
+	switch {
+	case width <= 0:
+		err = &ParameterError{"tile width", width, "it must be positive"}
+	case height <= 0:
+		err = &ParameterError{"tile height", height, "it must be positive"}
+	}
+	return set(err, func(o *pictureOptions) { o.tileWidth, o.tileHeight = width, height })
+}
+
+// WithMaxIterations sets the maximum number of iterations per point.
+func WithMaxIterations(maxIterations int) Option {
+	var err error
+	if maxIterations <= 0 {
+		err = &ParameterError{"max iterations", maxIterations, "it must be positive"}
+	}
+	return set(err, func(o *pictureOptions) { o.picture.MaxIterations = maxIterations })
+}
+
+// WithWorkers sets the number of goroutines used by Picture.Calculate, see Picture.Workers.
+func WithWorkers(workers int) Option {
+	var err error
+	if workers <= 0 {
+		err = &ParameterError{"workers", workers, "it must be positive"}
+	}
+	return set(err, func(o *pictureOptions) { o.picture.Workers = workers })
+}
+
+// WithFormula sets the function iterated for each point.
+func WithFormula(formula Formula) Option {
+	var err error
+	if formula == nil {
+		err = &ParameterError{"formula", formula, "it can't be nil"}
+	}
+	return set(err, func(o *pictureOptions) { o.picture.Formula = formula })
+}
+
+// WithJulia draws the Julia set of c instead of the Mandelbrot set.
+func WithJulia(c complex128) Option {
+	return set(nil, func(o *pictureOptions) {
+		o.picture.Julia = true
+		o.picture.C = c
+	})
+}
+
+// WithBailout sets the escape radius.
+func WithBailout(bailout float64) Option {
+	var err error
+	if bailout <= 0 || math.IsInf(bailout, 0) || math.IsNaN(bailout) {
+		err = &ParameterError{"bailout", bailout, "it must be a positive number"}
+	}
+	return set(err, func(o *pictureOptions) { o.picture.Bailout = bailout })
+}
+
+// WithPrecision calculates the points with the given bits of precision, see Picture.Precision.
+func WithPrecision(bits uint) Option {
+	var err error
+	if bits == 0 {
+		err = &ParameterError{"precision", bits, "it must be positive"}
+	}
+	return set(err, func(o *pictureOptions) { o.picture.Precision = bits })
+}
+
+// WithPerturbation enables the perturbation method, see Picture.Perturbation. It requires WithPrecision.
+func WithPerturbation() Option {
+	return set(nil, func(o *pictureOptions) { o.picture.Perturbation = true })
+}
+
+// WithDistance enables the distance estimation of the points, see Params.Distance.
+func WithDistance() Option {
+	return set(nil, func(o *pictureOptions) { o.picture.Distance = true })
+}
+
+// WithInterior stops early the points proven to be inside the set, see Params.Interior.
+func WithInterior() Option {
+	return set(nil, func(o *pictureOptions) { o.picture.Interior = true })
+}
+
+// WithSamples calculates samples points per pixel placed with the given pattern, see Area.Samples.
+func WithSamples(samples int, pattern SamplingPattern) Option {
+	var err error
+	switch {
+	case samples <= 0:
+		err = &ParameterError{"samples", samples, "it must be positive"}
+	case !pattern.valid():
+		err = &ParameterError{"sampling pattern", int(pattern), "it is not a known pattern"}
+	}
+	return set(err, func(o *pictureOptions) {
+		o.picture.Samples = samples
+		o.picture.Sampling = pattern
+	})
+}
+
+// WithAdaptiveSampling enables the adaptive supersampling, see Area.AdaptiveDepth.
+func WithAdaptiveSampling(depth int, threshold float64) Option {
+	var err error
+	switch {
+	case depth <= 0:
+		err = &ParameterError{"adaptive depth", depth, "it must be positive"}
+	case threshold < 0 || math.IsNaN(threshold):
+		err = &ParameterError{"adaptive threshold", threshold, "it can't be negative"}
+	}
+	return set(err, func(o *pictureOptions) {
+		o.picture.AdaptiveDepth = depth
+		o.picture.AdaptiveThreshold = threshold
+	})
+}
+
+// WithStrategy chooses how the points of each area are visited.
+func WithStrategy(strategy Strategy) Option {
+	var err error
+	if !strategy.valid() {
+		err = &ParameterError{"strategy", int(strategy), "it is not a known strategy"}
+	}
+	return set(err, func(o *pictureOptions) { o.picture.Strategy = strategy })
+}
+
+// WithTileOrder sets the order in which the areas are calculated.
+func WithTileOrder(order TileOrder) Option {
+	var err error
+	if !order.valid() {
+		err = &ParameterError{"tile order", int(order), "it is not a known order"}
+	}
+	return set(err, func(o *pictureOptions) { o.picture.Order = order })
+}
+
+// WithScheduler sets how the areas are shared between the workers.
+func WithScheduler(scheduler Scheduler) Option {
+	var err error
+	if !scheduler.valid() {
+		err = &ParameterError{"scheduler", int(scheduler), "it is not a known scheduler"}
+	}
+	return set(err, func(o *pictureOptions) { o.picture.Scheduler = scheduler })
+}
+
+// WithoutSymmetry calculates every area even if the picture is symmetric, see Picture.DisableSymmetry.
+func WithoutSymmetry() Option {
+	return set(nil, func(o *pictureOptions) { o.picture.DisableSymmetry = true })
+}
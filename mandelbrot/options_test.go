@@ -0,0 +1,95 @@
+package mandelbrot_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+func TestPictureWithOptions(t *testing.T) {
+	pic, err := NewPictureWithOptions(100, 70,
+		WithRegion(complex(-2, 1), 3),
+		WithTileSize(30, 20),
+		WithMaxIterations(300),
+		WithWorkers(3),
+		WithFormula(Multibrot{Power: 3}),
+		WithBailout(100),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pic.HorizontalResolution() != 100 || pic.VerticalResolution() != 70 {
+		t.Errorf("Expected a 100x70 picture, got %dx%d", pic.HorizontalResolution(), pic.VerticalResolution())
+	}
+	if pic.HorizontalImageChunks != 4 || pic.VerticalImageChunks != 4 {
+		t.Errorf("Expected 4x4 areas, got %dx%d", pic.HorizontalImageChunks, pic.VerticalImageChunks)
+	}
+	if pic.TopLeft != complex(-2, 1) || pic.MaxIterations != 300 || pic.Workers != 3 || pic.Bailout != 100 {
+		t.Errorf("Options not applied, got %+v", pic)
+	}
+	if pic.Formula != (Multibrot{Power: 3}) {
+		t.Errorf("Expected formula multibrot3, got %s", pic.Formula)
+	}
+
+	pic.Init()
+	done := 0
+	for range pic.CalculateAsync(context.Background(), 0) {
+		done++
+	}
+	if done != 16 {
+		t.Errorf("Expected 16 areas calculated, got %d", done)
+	}
+}
+
+func TestPictureWithOptionsMatchesView(t *testing.T) {
+	view := View{Center: complex(-0.75, 0.1), Zoom: 20, Aspect: 2}
+	expected, err := view.Picture(60, 3, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewPictureWithOptions(60, 30, WithView(view), WithTileSize(20, 10), WithMaxIterations(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TopLeft != expected.TopLeft || got.ChunkSize != expected.ChunkSize {
+		t.Errorf("Expected top left %f and chunk size %f, got %f and %f", expected.TopLeft, expected.ChunkSize, got.TopLeft, got.ChunkSize)
+	}
+}
+
+func TestPictureWithInvalidOptions(t *testing.T) {
+	tests := []struct {
+		parameter string
+		options   []Option
+	}{
+		{"image width", nil},
+		{"tile width", []Option{WithTileSize(0, 10)}},
+		{"tile height", []Option{WithTileSize(10, 200)}},
+		{"max iterations", []Option{WithMaxIterations(0)}},
+		{"workers", []Option{WithWorkers(-1)}},
+		{"formula", []Option{WithFormula(nil)}},
+		{"formula", []Option{WithFormula(BurningShip{}), WithDistance()}},
+		{"bailout", []Option{WithBailout(-2)}},
+		{"precision", []Option{WithPrecision(0)}},
+		{"perturbation", []Option{WithPerturbation()}},
+		{"samples", []Option{WithSamples(0, GridSampling)}},
+		{"adaptive depth", []Option{WithAdaptiveSampling(0, 1)}},
+		{"area width", []Option{WithRegion(complex(-2, 1), 0)}},
+		{"sampling pattern", []Option{WithSamples(4, SamplingPattern(7))}},
+		{"strategy", []Option{WithStrategy(Strategy(-1))}},
+		{"tile order", []Option{WithTileOrder(TileOrder(5))}},
+		{"scheduler", []Option{WithScheduler(Scheduler(2))}},
+	}
+
+	for i, test := range tests {
+		width := 100
+		if test.options == nil {
+			width = 0
+		}
+		pic, err := NewPictureWithOptions(width, 100, test.options...)
+		parameterErr, ok := err.(*ParameterError)
+		if pic != nil || !ok || parameterErr.Parameter != test.parameter {
+			t.Errorf("Test %d expected an error for %s, got %v", i, test.parameter, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package mandelbrot
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// TileOrder chooses the order in which the areas of a Picture are calculated. It does not change the result, but
+// it decides which parts of the image are available first when the calculation is cancelled.
+type TileOrder int
+
+const (
+	// RowMajorOrder calculates the areas from left to right and from top to bottom.
+	RowMajorOrder TileOrder = iota
+	// CenterOutOrder calculates first the areas closer to the center of the picture.
+	CenterOutOrder
+	// SpiralOrder walks the areas in a square spiral that starts at the center of the picture.
+	SpiralOrder
+	// HilbertOrder follows a Hilbert curve, so consecutive areas are always close to each other.
+	HilbertOrder
+	// RandomOrder calculates the areas in a random order, the same on every calculation.
+	RandomOrder
+)
+
+// orderSeed makes the random order the same on every calculation.
+const orderSeed = 1
+
+// String returns the name of the order.
+func (o TileOrder) String() string {
+	switch o {
+	case RowMajorOrder:
+		return "rowmajor"
+	case CenterOutOrder:
+		return "centerout"
+	case SpiralOrder:
+		return "spiral"
+	case HilbertOrder:
+		return "hilbert"
+	case RandomOrder:
+		return "random"
+	}
+	return "unknown"
+}
+
+// tileOrders are all the known orders.
+var tileOrders = []TileOrder{RowMajorOrder, CenterOutOrder, SpiralOrder, HilbertOrder, RandomOrder}
+
+// valid reports whether o is one of the known orders.
+func (o TileOrder) valid() bool {
+	for _, order := range tileOrders {
+		if o == order {
+			return true
+		}
+	}
+	return false
+}
+
+// TileOrderByName returns the order with the given name, see TileOrder.String.
+func TileOrderByName(name string) (TileOrder, bool) {
+	for _, order := range tileOrders {
+		if order.String() == name {
+			return order, true
+		}
+	}
+	return 0, false
+}
+
+// areaOrder returns the indexes of all the areas of the picture in the order they must be calculated.
+func (p *Picture) areaOrder() []int {
+	columns, rows := p.HorizontalImageChunks, p.VerticalImageChunks
+	order := make([]int, 0, columns*rows)
+	switch p.Order {
+	case CenterOutOrder:
+		for i := 0; i < columns*rows; i++ {
+			order = append(order, i)
+		}
+		distances := make([]float64, len(order))
+		for i := range distances {
+			distances[i] = p.distanceToCenter(i)
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return distances[order[i]] < distances[order[j]]
+		})
+	case SpiralOrder:
+		x, y := (columns-1)/2, (rows-1)/2
+		directions := [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+		// The spiral turns after each side, and its sides grow every two turns.
+		for side, turn := 1, 0; len(order) < columns*rows; turn++ {
+			direction := directions[turn%4]
+			for step := 0; step < side; step++ {
+				if x >= 0 && y >= 0 && x < columns && y < rows {
+					order = append(order, p.IndexFor(x, y))
+				}
+				x, y = x+direction[0], y+direction[1]
+			}
+			if turn%2 == 1 {
+				side++
+			}
+		}
+	case HilbertOrder:
+		size := 1
+		for size < columns || size < rows {
+			size *= 2
+		}
+		for d := 0; d < size*size; d++ {
+			x, y := hilbertPosition(size, d)
+			if x < columns && y < rows {
+				order = append(order, p.IndexFor(x, y))
+			}
+		}
+	default:
+		for i := 0; i < columns*rows; i++ {
+			order = append(order, i)
+		}
+		if p.Order == RandomOrder {
+			random := rand.New(rand.NewSource(orderSeed))
+			random.Shuffle(len(order), func(i, j int) {
+				order[i], order[j] = order[j], order[i]
+			})
+		}
+	}
+	return order
+}
+
+// distanceToCenter returns the squared distance in pixels from the center of the area i to the center of the picture.
+func (p *Picture) distanceToCenter(i int) float64 {
+	x, y := p.ForIndex(i)
+	offsetX, offsetY := p.GetImageOffsetFor(i)
+	width, height := p.areaResolution(x, y)
+	dx := float64(offsetX) + float64(width)/2 - float64(p.HorizontalResolution())/2
+	dy := float64(offsetY) + float64(height)/2 - float64(p.VerticalResolution())/2
+	return dx*dx + dy*dy
+}
+
+// hilbertPosition returns the position of the step d of a Hilbert curve that fills a grid of size by size,
+// size must be a power of two.
+func hilbertPosition(size, d int) (x, y int) {
+	for s := 1; s < size; s *= 2 {
+		rx := 1 & (d / 2)
+		ry := 1 & (d ^ rx)
+		if ry == 0 {
+			if rx == 1 {
+				x, y = s-1-x, s-1-y
+			}
+			x, y = y, x
+		}
+		x += s * rx
+		y += s * ry
+		d /= 4
+	}
+	return x, y
+}
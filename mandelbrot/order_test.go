@@ -0,0 +1,117 @@
+package mandelbrot_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+// calculationOrder returns the indexes of the areas in the order they are calculated by a single worker.
+func calculationOrder(t *testing.T, order TileOrder, width, height, tile int) (*Picture, []int) {
+	pic, err := NewPictureWithOptions(width, height, WithTileSize(tile, tile), WithMaxIterations(20), WithTileOrder(order), WithoutSymmetry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	var indexes []int
+	for i := range pic.CalculateAsync(context.Background(), 1) {
+		indexes = append(indexes, i)
+	}
+
+	seen := make(map[int]bool)
+	for _, i := range indexes {
+		seen[i] = true
+	}
+	if areas := pic.HorizontalImageChunks * pic.VerticalImageChunks; len(indexes) != areas || len(seen) != areas {
+		t.Fatalf("%s order expected to calculate %d areas once, got %v", order, areas, indexes)
+	}
+	return pic, indexes
+}
+
+func TestTileOrderRowMajor(t *testing.T) {
+	_, indexes := calculationOrder(t, RowMajorOrder, 50, 30, 10)
+	for i, index := range indexes {
+		if i != index {
+			t.Fatalf("Expected areas in index order, got %v", indexes)
+		}
+	}
+}
+
+func TestTileOrderCenterOut(t *testing.T) {
+	pic, indexes := calculationOrder(t, CenterOutOrder, 50, 30, 10)
+	if indexes[0] != pic.IndexFor(2, 1) {
+		t.Errorf("Expected the center area first, got %v", indexes)
+	}
+	for _, corner := range []int{pic.IndexFor(0, 0), pic.IndexFor(4, 0), pic.IndexFor(0, 2), pic.IndexFor(4, 2)} {
+		found := false
+		for _, index := range indexes[len(indexes)-4:] {
+			found = found || index == corner
+		}
+		if !found {
+			t.Errorf("Expected the corner %d last, got %v", corner, indexes)
+		}
+	}
+}
+
+func TestTileOrderSpiral(t *testing.T) {
+	pic, indexes := calculationOrder(t, SpiralOrder, 70, 70, 10)
+	if indexes[0] != pic.IndexFor(3, 3) {
+		t.Errorf("Expected the center area first, got %v", indexes)
+	}
+	assertAdjacent(t, pic, indexes)
+}
+
+func TestTileOrderHilbert(t *testing.T) {
+	pic, indexes := calculationOrder(t, HilbertOrder, 80, 80, 10)
+	assertAdjacent(t, pic, indexes)
+
+	// Images that are not a power of two skip the positions outside of them.
+	calculationOrder(t, HilbertOrder, 50, 30, 10)
+}
+
+func TestTileOrderRandom(t *testing.T) {
+	_, first := calculationOrder(t, RandomOrder, 50, 30, 10)
+	_, second := calculationOrder(t, RandomOrder, 50, 30, 10)
+	sorted := true
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected the same order on every calculation, got %v and %v", first, second)
+		}
+		sorted = sorted && first[i] == i
+	}
+	if sorted {
+		t.Errorf("Expected a random order, got %v", first)
+	}
+}
+
+func TestTileOrderByName(t *testing.T) {
+	for _, order := range []TileOrder{RowMajorOrder, CenterOutOrder, SpiralOrder, HilbertOrder, RandomOrder} {
+		got, ok := TileOrderByName(order.String())
+		if !ok || got != order {
+			t.Errorf("Expected order %s, got %s", order, got)
+		}
+	}
+	if _, ok := TileOrderByName("diagonal"); ok {
+		t.Error("Expected unknown order")
+	}
+}
+
+// assertAdjacent checks that consecutive areas share a side.
+func assertAdjacent(t *testing.T, pic *Picture, indexes []int) {
+	t.Helper()
+	for i := 1; i < len(indexes); i++ {
+		x1, y1 := pic.ForIndex(indexes[i-1])
+		x2, y2 := pic.ForIndex(indexes[i])
+		if abs(x1-x2)+abs(y1-y2) != 1 {
+			t.Fatalf("Areas %d,%d and %d,%d are not adjacent in %v", x1, y1, x2, y2, indexes)
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,174 @@
+package mandelbrot
+
+import "math/big"
+
+// glitchTolerance is the minimum ratio between |z| and the reference |Z| for a perturbed orbit to be trusted.
+// Below it the delta has lost too many bits against the reference and the point is considered glitched.
+const glitchTolerance = 1e-3
+
+// maxReferences is the number of references, including the main one, used to fix the glitches of an area.
+// The remaining glitched points are calculated with full precision.
+const maxReferences = 8
+
+// Reference is an orbit calculated with full precision that is used as the base of the perturbation method.
+// The points around it are iterated as complex128 deltas against the orbit, which is much faster than iterating them with math/big.
+type Reference struct {
+	Point BigComplex
+	// Orbit contains the values of z for each iteration rounded to complex128, Orbit[0] is the starting value.
+	Orbit []complex128
+
+	zr      *big.Float
+	zi      *big.Float
+	escaped bool
+}
+
+// NewReference calculates the orbit of point with the given params.
+func NewReference(point BigComplex, params Params) *Reference {
+	r := &Reference{
+		Point: point,
+	}
+	r.Calculate(params)
+	return r
+}
+
+// Calculate extends the orbit up to params.MaxIterations, it stops earlier if the orbit escapes.
+func (r *Reference) Calculate(params Params) {
+	bailout := params.Bailout
+	if bailout == 0 {
+		bailout = DefaultBailout
+	}
+	precision := r.Point.Real.Prec()
+	newFloat := func() *big.Float { return new(big.Float).SetPrec(precision) }
+	if r.zr == nil {
+		r.zr, r.zi = newFloat(), newFloat()
+		if params.Julia {
+			r.zr.Set(r.Point.Real)
+			r.zi.Set(r.Point.Imag)
+		}
+		r.Orbit = append(r.Orbit, BigComplex{Real: r.zr, Imag: r.zi}.Complex128())
+	}
+	cr, ci := r.Point.Real, r.Point.Imag
+	if params.Julia {
+		cr, ci = newFloat().SetFloat64(real(params.C)), newFloat().SetFloat64(imag(params.C))
+	}
+
+	zr, zi := r.zr, r.zi
+	zr2, zi2, abs := newFloat(), newFloat(), newFloat()
+	bailoutSquared := newFloat().SetFloat64(bailout * bailout)
+
+	for !r.escaped && len(r.Orbit) <= params.MaxIterations {
+		zr2.Mul(zr, zr)
+		zi2.Mul(zi, zi)
+		zi.Mul(zi, zr)
+		zi.Add(zi, zi)
+		zi.Add(zi, ci)
+		zr.Sub(zr2, zi2)
+		zr.Add(zr, cr)
+		r.Orbit = append(r.Orbit, BigComplex{Real: zr, Imag: zi}.Complex128())
+
+		zr2.Mul(zr, zr)
+		zi2.Mul(zi, zi)
+		r.escaped = abs.Add(zr2, zi2).Cmp(bailoutSquared) >= 0
+	}
+}
+
+// perturb iterates the point located at delta from the reference. It reports false if the result can't be trusted
+// because the point has glitched or because the reference orbit is too short.
+func (r *Reference) perturb(m *Point, delta complex128, params Params) bool {
+	bailout := params.Bailout
+	if bailout == 0 {
+		bailout = DefaultBailout
+	}
+	bailoutSquared := bailout * bailout
+	orbit := r.Orbit
+
+	// For the Julia set, the delta is the difference between the starting values and c is the same for all the points.
+	var dz, dc complex128
+	if params.Julia {
+		dz = delta
+	} else {
+		dc = delta
+	}
+	z := orbit[0] + dz
+	iterations := 0
+
+	// The derivative of the orbit is tracked on the full value of z.
+	derivative, derivativeC := complex(0, 0), complex(1, 0)
+	if params.Julia {
+		derivative, derivativeC = 1, 0
+	}
+
+	for real(z)*real(z)+imag(z)*imag(z) < bailoutSquared && iterations < params.MaxIterations {
+		if iterations+1 >= len(orbit) {
+			return false
+		}
+		if params.Distance {
+			derivative = 2*z*derivative + derivativeC
+		}
+		dz = 2*orbit[iterations]*dz + dz*dz + dc
+		iterations++
+		reference := orbit[iterations]
+		z = reference + dz
+
+		zAbs := real(z)*real(z) + imag(z)*imag(z)
+		referenceAbs := real(reference)*real(reference) + imag(reference)*imag(reference)
+		if zAbs < glitchTolerance*glitchTolerance*referenceAbs {
+			return false
+		}
+	}
+
+	m.iterations = iterations
+	m.z = z
+	m.dz = derivative
+	if params.Distance {
+		m.distance = distanceEstimation(z, derivative)
+	}
+	m.escaped = real(z)*real(z)+imag(z)*imag(z) >= bailoutSquared
+	if m.escaped {
+		m.smooth = smoothIterations(iterations, z, 2)
+	}
+	return true
+}
+
+// calculatePerturbation iterates all the points of the area against the Reference.
+// Glitched points are calculated again against new references located at one of them and if there are still glitched points
+// after maxReferences, they are calculated with full precision.
+func (a *Area) calculatePerturbation() {
+	params := a.params()
+	topLeft := a.bigTopLeft()
+
+	glitched := make([]int, len(a.Points))
+	for i := range glitched {
+		glitched[i] = i
+	}
+
+	reference := a.Reference
+	for references := 0; len(glitched) > 0; references++ {
+		if references == maxReferences {
+			for _, i := range glitched {
+				x, y := a.ForIndex(i)
+				bigPoint := NewBigPoint(topLeft.Add(a.getOffset(x, y)))
+				bigPoint.CalculateParams(params)
+				a.Points[i] = bigPoint.point()
+			}
+			return
+		}
+
+		if references > 0 {
+			// The new reference is placed at the middle glitched point, it is likely to be in the center of the glitch.
+			x, y := a.ForIndex(glitched[len(glitched)/2])
+			reference = NewReference(topLeft.Add(a.getOffset(x, y)), params)
+		}
+
+		areaDelta := topLeft.Sub(reference.Point)
+		remaining := glitched[:0]
+		for _, i := range glitched {
+			x, y := a.ForIndex(i)
+			a.Points[i] = Point{Point: a.Points[i].Point}
+			if !reference.perturb(&a.Points[i], areaDelta+a.getOffset(x, y), params) {
+				remaining = append(remaining, i)
+			}
+		}
+		glitched = remaining
+	}
+}
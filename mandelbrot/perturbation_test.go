@@ -0,0 +1,178 @@
+package mandelbrot_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+// newDeepArea returns an area of width 2e-21 next to -2, where complex128 can't tell apart the points.
+// The orbits around -2 are so sensitive that after 50 iterations the result depends on the rounding errors, so MaxIterations is kept low.
+func newDeepArea(t *testing.T) *Area {
+	topLeft, err := ParseBigComplex("-2.000000000000000000001", "0.000000000000000000001", 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bottomRight := topLeft.Add(complex(2e-21, -2e-21))
+	return &Area{
+		HorizontalResolution: 16,
+		VerticalResolution:   16,
+		MaxIterations:        50,
+		Bailout:              4,
+		Precision:            128,
+		TopLeft:              topLeft.Complex128(),
+		BottomRight:          bottomRight.Complex128(),
+		BigTopLeft:           &topLeft,
+		BigBottomRight:       &bottomRight,
+	}
+}
+
+func comparePoints(t *testing.T, expected, got []Point) {
+	for i := range expected {
+		if expected[i].Iterations() != got[i].Iterations() || expected[i].Escaped() != got[i].Escaped() {
+			t.Errorf("Point %d expected %d iterations, got %d", i, expected[i].Iterations(), got[i].Iterations())
+		}
+	}
+}
+
+func TestPerturbationMatchesPrecision(t *testing.T) {
+	expected := newDeepArea(t)
+	expected.Init()
+	expected.Calculate()
+
+	area := newDeepArea(t)
+	area.Reference = NewReference(area.BigTopLeft.Add(complex(1e-21, -1e-21)), Params{MaxIterations: area.MaxIterations, Bailout: area.Bailout})
+	area.Init()
+	area.Calculate()
+
+	comparePoints(t, expected.Points, area.Points)
+}
+
+func TestPerturbationDeepen(t *testing.T) {
+	expected := newDeepArea(t)
+	expected.Init()
+	expected.Calculate()
+
+	// The reference is only calculated up to the first MaxIterations, Deepen has to extend it.
+	area := newDeepArea(t)
+	area.MaxIterations = 20
+	area.Reference = NewReference(area.BigTopLeft.Add(complex(1e-21, -1e-21)), Params{MaxIterations: area.MaxIterations, Bailout: area.Bailout})
+	area.Init()
+	area.Calculate()
+	area.Deepen(50)
+
+	if len(area.Reference.Orbit) <= 21 {
+		t.Errorf("Expected the reference orbit extended, it has %d values", len(area.Reference.Orbit))
+	}
+	comparePoints(t, expected.Points, area.Points)
+}
+
+func TestPerturbationDistance(t *testing.T) {
+	expected := newDeepArea(t)
+	expected.Distance = true
+	expected.Init()
+	expected.Calculate()
+
+	area := newDeepArea(t)
+	area.Distance = true
+	area.Reference = NewReference(area.BigTopLeft.Add(complex(1e-21, -1e-21)), Params{MaxIterations: area.MaxIterations, Bailout: area.Bailout})
+	area.Init()
+	area.Calculate()
+
+	for i := range expected.Points {
+		if expected.Points[i].Distance() == 0 {
+			continue
+		}
+		if ratio := area.Points[i].Distance() / expected.Points[i].Distance(); math.Abs(ratio-1) > 1e-6 {
+			t.Errorf("Point %d expected distance %g, got %g", i, expected.Points[i].Distance(), area.Points[i].Distance())
+		}
+		// The set crosses the area and the estimation is at most 4 times the real distance.
+		if area.Points[i].Distance() > 4*area.PixelSize()*float64(area.HorizontalResolution) {
+			t.Errorf("Point %d distance %g is larger than the area", i, area.Points[i].Distance())
+		}
+	}
+}
+
+func TestPerturbationGlitches(t *testing.T) {
+	expected := newDeepArea(t)
+	expected.Init()
+	expected.Calculate()
+
+	// The top left corner escapes much earlier than other points of the area, which makes them glitch.
+	area := newDeepArea(t)
+	area.Reference = NewReference(*area.BigTopLeft, Params{MaxIterations: area.MaxIterations, Bailout: area.Bailout})
+	if len(area.Reference.Orbit) > 40 {
+		t.Fatalf("Reference orbit is expected to escape early, got %d iterations", len(area.Reference.Orbit))
+	}
+	area.Init()
+	area.Calculate()
+
+	comparePoints(t, expected.Points, area.Points)
+}
+
+func TestPicturePerturbation(t *testing.T) {
+	topLeft, err := ParseBigComplex("-2.000000000000000000001", "0.000000000000000000001", 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPicture := func(perturbation bool) *Picture {
+		pic := &Picture{
+			TopLeft:               topLeft.Complex128(),
+			BigTopLeft:            &topLeft,
+			Precision:             128,
+			Perturbation:          perturbation,
+			MaxIterations:         50,
+			Bailout:               4,
+			ChunkSize:             1e-21,
+			HorizontalImageChunks: 2,
+			VerticalImageChunks:   2,
+			ChunkImageSize:        8,
+		}
+		pic.Init()
+		for range pic.CalculateAsync(context.Background(), 2) {
+		}
+		return pic
+	}
+
+	expected := newPicture(false)
+	got := newPicture(true)
+	for i := 0; i < 4; i++ {
+		comparePoints(t, expected.GetArea(i).Points, got.GetArea(i).Points)
+	}
+}
+
+func TestPerturbationJulia(t *testing.T) {
+	// The area is at the border of the Julia set of -0.5+0.5i.
+	newArea := func() *Area {
+		topLeft, err := ParseBigComplex("0.766395591784249029247", "0.000000000000000000001", 128)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bottomRight := topLeft.Add(complex(2e-21, -2e-21))
+		return &Area{
+			HorizontalResolution: 16,
+			VerticalResolution:   16,
+			MaxIterations:        150,
+			Julia:                true,
+			C:                    complex(-0.5, 0.5),
+			Precision:            128,
+			TopLeft:              topLeft.Complex128(),
+			BottomRight:          bottomRight.Complex128(),
+			BigTopLeft:           &topLeft,
+			BigBottomRight:       &bottomRight,
+		}
+	}
+
+	expected := newArea()
+	expected.Init()
+	expected.Calculate()
+
+	area := newArea()
+	area.Reference = NewReference(area.BigTopLeft.Add(complex(1e-21, -1e-21)), Params{MaxIterations: area.MaxIterations, Julia: true, C: area.C})
+	area.Init()
+	area.Calculate()
+
+	comparePoints(t, expected.Points, area.Points)
+}
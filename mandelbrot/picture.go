@@ -3,81 +3,371 @@ package mandelbrot
 import (
 	"context"
 	"fmt"
-	"log"
+	"math"
 	"runtime/trace"
 	"sync"
 )
 
 type Picture struct {
-	TopLeft       complex128
-	ChunkSize     float64
+	// TopLeft is the corner of the picture before the rotation.
+	TopLeft complex128
+	// ChunkSize is the width of each area in the complex plane, the height is given by the shape of the areas in pixels
+	// so the pixels are always square.
+	ChunkSize float64
+	// Rotation is the angle in radians that the picture is turned counterclockwise around its center.
+	Rotation      float64
 	MaxIterations int
+	Bailout       float64
+	// Julia calculates the Julia set of C instead of the Mandelbrot set.
+	Julia bool
+	C     complex128
+	// Formula is the function iterated for each point, the Mandelbrot formula is used when it is nil.
+	Formula Formula
+	// Distance enables the distance estimation of the points, see Point.Distance.
+	Distance bool
+	// Interior stops early the points proven to be inside the set, see Params.Interior.
+	Interior bool
+	// Samples and Sampling enable the supersampling of the areas, see Area.Samples.
+	Samples  int
+	Sampling SamplingPattern
+	// AdaptiveDepth and AdaptiveThreshold enable the adaptive supersampling of the areas, see Area.AdaptiveDepth.
+	AdaptiveDepth     int
+	AdaptiveThreshold float64
+	// Strategy chooses how the points of the areas are visited, see Area.Strategy.
+	Strategy Strategy
+	// DisableSymmetry calculates every area. Otherwise, when the picture straddles the real axis and its rows are
+	// aligned across it, the areas below the axis are copied from the conjugate of the rows above it for formulas that
+	// are ConjugateSymmetric. It is not used with rotation, supersampling or high precision.
+	DisableSymmetry bool
+	// Precision is the number of bits used to calculate the areas, zero means complex128.
+	Precision uint
+	// BigTopLeft is the high precision version of TopLeft used when Precision is set.
+	BigTopLeft *BigComplex
+	// Perturbation calculates a single reference orbit at the center of the picture with Precision and iterates the rest
+	// of the points as complex128 deltas against it. It is only available for the Mandelbrot formula.
+	Perturbation bool
 
 	HorizontalImageChunks int
 	VerticalImageChunks   int
 	ChunkImageSize        int
+	// ChunkImageWidth and ChunkImageHeight are the resolution of each area for non square areas.
+	// ChunkImageSize is used for any of them that is zero.
+	ChunkImageWidth  int
+	ChunkImageHeight int
+	// ImageWidth and ImageHeight are the size of the image when it is not a multiple of the size of the areas, the
+	// areas at the right and bottom edges are cut to fit in it. The image is as large as all the areas when they are zero.
+	ImageWidth  int
+	ImageHeight int
+	// Order is the order in which the areas are calculated, RowMajorOrder is used by default.
+	Order TileOrder
+	// Scheduler shares the areas between the workers, QueueScheduler is used by default.
+	Scheduler Scheduler
+	// Workers is the number of goroutines used by Calculate when it is not given a positive number.
+	Workers int
 
-	areas []Area
+	areas     []Area
+	reference *Reference
+	// complete tells which areas are completely calculated, it is guarded by mutex so it can be checked while the
+	// picture is calculated.
+	complete []bool
+	mutex    sync.Mutex
+	// calculating is held while the areas are calculated, so a calculation waits for the areas that were still being
+	// calculated when the previous one was cancelled.
+	calculating sync.Mutex
 }
 
-func NewPicture(topLeft complex128, chunkSize float64, imageSize int, divisions int, maxIterations int) *Picture {
-	if imageSize%divisions != 0 {
-		log.Printf("WARNING: ImageSize %d can't be divided in %d divisions, The final image will be smaller", imageSize, divisions)
+// ParameterError is returned by the constructors of Picture when one of the parameters is not valid.
+type ParameterError struct {
+	Parameter string
+	Value     interface{}
+	Reason    string
+}
+
+func (e *ParameterError) Error() string {
+	return fmt.Sprintf("invalid %s %v, %s", e.Parameter, e.Value, e.Reason)
+}
+
+// NewPicture returns a squared picture of imageSize pixels split in divisions by divisions areas. chunkSize is the
+// width of the picture in the complex plane. If imageSize can't be divided in divisions, the areas at the right and
+// bottom edges are smaller so the image has exactly imageSize pixels, see NewRectangularPicture.
+func NewPicture(topLeft complex128, chunkSize float64, imageSize int, divisions int, maxIterations int) (*Picture, error) {
+	return NewRectangularPicture(topLeft, chunkSize, imageSize, imageSize, divisions, divisions, maxIterations)
+}
+
+// NewRectangularPicture works like NewPicture for images of any shape. areaWidth is the width of the picture in the
+// complex plane, the height is chosen so the pixels are square. The image is split in horizontalDivisions by
+// verticalDivisions areas. All the areas have the same size except the ones at the edges, so there may be fewer areas
+// than divisions when they are close to the size of the image, for example 10 pixels in 6 divisions are split in 5
+// areas of 2 pixels. HorizontalImageChunks and VerticalImageChunks have the actual number of areas.
+func NewRectangularPicture(topLeft complex128, areaWidth float64, imageWidth, imageHeight int, horizontalDivisions, verticalDivisions int, maxIterations int) (*Picture, error) {
+	switch {
+	case areaWidth <= 0 || math.IsInf(areaWidth, 0) || math.IsNaN(areaWidth):
+		return nil, &ParameterError{"area width", areaWidth, "it must be a positive number"}
+	case imageWidth <= 0:
+		return nil, &ParameterError{"image width", imageWidth, "it must be positive"}
+	case imageHeight <= 0:
+		return nil, &ParameterError{"image height", imageHeight, "it must be positive"}
+	case horizontalDivisions <= 0 || horizontalDivisions > imageWidth:
+		return nil, &ParameterError{"horizontal divisions", horizontalDivisions, fmt.Sprintf("it must be between 1 and the image width %d", imageWidth)}
+	case verticalDivisions <= 0 || verticalDivisions > imageHeight:
+		return nil, &ParameterError{"vertical divisions", verticalDivisions, fmt.Sprintf("it must be between 1 and the image height %d", imageHeight)}
+	case maxIterations <= 0:
+		return nil, &ParameterError{"max iterations", maxIterations, "it must be positive"}
 	}
-	chunkImageSize := imageSize / divisions
-	return &Picture{
-		TopLeft:               topLeft,
-		MaxIterations:         maxIterations,
-		ChunkSize:             chunkSize / float64(divisions),
-		HorizontalImageChunks: divisions,
-		VerticalImageChunks:   divisions,
-		ChunkImageSize:        chunkImageSize,
+
+	chunkImageWidth := (imageWidth + horizontalDivisions - 1) / horizontalDivisions
+	chunkImageHeight := (imageHeight + verticalDivisions - 1) / verticalDivisions
+	p := &Picture{MaxIterations: maxIterations}
+	p.tile(topLeft, areaWidth, imageWidth, imageHeight, chunkImageWidth, chunkImageHeight)
+	return p, nil
+}
+
+// tile places the picture at topLeft and splits it in areas of chunkImageWidth by chunkImageHeight pixels, the
+// parameters must be already validated.
+func (p *Picture) tile(topLeft complex128, areaWidth float64, imageWidth, imageHeight int, chunkImageWidth, chunkImageHeight int) {
+	chunkSize := areaWidth / float64(imageWidth) * float64(chunkImageWidth)
+	if imageWidth%chunkImageWidth == 0 {
+		chunkSize = areaWidth / float64(imageWidth/chunkImageWidth)
 	}
+	p.TopLeft = topLeft
+	p.ChunkSize = chunkSize
+	p.HorizontalImageChunks = (imageWidth + chunkImageWidth - 1) / chunkImageWidth
+	p.VerticalImageChunks = (imageHeight + chunkImageHeight - 1) / chunkImageHeight
+	p.ChunkImageWidth = chunkImageWidth
+	p.ChunkImageHeight = chunkImageHeight
+	p.ImageWidth = imageWidth
+	p.ImageHeight = imageHeight
 }
 
 func (p *Picture) Init() {
+	chunkHeight := p.ChunkSize * float64(p.chunkImageHeight()) / float64(p.chunkImageWidth())
+	center := complex(
+		p.ChunkSize*(float64(p.HorizontalResolution())/float64(p.chunkImageWidth()))/2,
+		-chunkHeight*(float64(p.VerticalResolution())/float64(p.chunkImageHeight()))/2,
+	)
+	p.reference = nil
+	if p.Perturbation && isMandelbrot(p.Formula) {
+		p.reference = NewReference(p.bigTopLeft().Add(center), p.params())
+	}
 	p.areas = make([]Area, p.HorizontalImageChunks*p.VerticalImageChunks)
+	p.complete = make([]bool, len(p.areas))
 	for i := 0; i < len(p.areas); i++ {
 		x, y := p.ForIndex(i)
-		areaTopLeft := p.TopLeft + complex(p.ChunkSize*float64(x), -p.ChunkSize*float64(y))
-		areaBottomRight := areaTopLeft + complex(p.ChunkSize, -p.ChunkSize)
+		width, height := p.areaResolution(x, y)
+		// The fraction of a whole area covered by the areas cut by the edges of the image.
+		fractionX, fractionY := float64(width)/float64(p.chunkImageWidth()), float64(height)/float64(p.chunkImageHeight())
+		// The areas are rotated around their center, so they are moved to place their center at its rotated position.
+		shift := complex(0, 0)
+		if p.Rotation != 0 {
+			fromCenter := complex(p.ChunkSize*(float64(x)+fractionX/2), -chunkHeight*(float64(y)+fractionY/2)) - center
+			shift = rotate(fromCenter, p.Rotation) - fromCenter
+		}
+		areaTopLeft := p.TopLeft + complex(p.ChunkSize*float64(x), -chunkHeight*float64(y)) + shift
+		areaBottomRight := areaTopLeft + complex(p.ChunkSize*fractionX, -chunkHeight*fractionY)
 
 		p.areas[i] = Area{
 			TopLeft:              areaTopLeft,
 			BottomRight:          areaBottomRight,
-			HorizontalResolution: p.ChunkImageSize,
-			VerticalResolution:   p.ChunkImageSize,
+			Rotation:             p.Rotation,
+			HorizontalResolution: width,
+			VerticalResolution:   height,
 			MaxIterations:        p.MaxIterations,
+			Bailout:              p.Bailout,
+			Julia:                p.Julia,
+			C:                    p.C,
+			Formula:              p.Formula,
+			Distance:             p.Distance,
+			Interior:             p.Interior,
+			Samples:              p.Samples,
+			Sampling:             p.Sampling,
+			AdaptiveDepth:        p.AdaptiveDepth,
+			AdaptiveThreshold:    p.AdaptiveThreshold,
+			Strategy:             p.Strategy,
+			Precision:            p.Precision,
+			Reference:            p.reference,
+		}
+		if p.Precision > 0 || p.Perturbation {
+			topLeft := p.bigTopLeft()
+			areaBigTopLeft := topLeft.Add(complex(p.ChunkSize*float64(x), -chunkHeight*float64(y)) + shift)
+			areaBigBottomRight := topLeft.Add(complex(p.ChunkSize*(float64(x)+fractionX), -chunkHeight*(float64(y)+fractionY)) + shift)
+			p.areas[i].BigTopLeft = &areaBigTopLeft
+			p.areas[i].BigBottomRight = &areaBigBottomRight
 		}
 		p.areas[i].Init()
 	}
 }
 
+// params returns the settings used to iterate the points of the picture.
+func (p *Picture) params() Params {
+	return Params{
+		MaxIterations: p.MaxIterations,
+		Bailout:       p.Bailout,
+		Julia:         p.Julia,
+		C:             p.C,
+		Formula:       p.Formula,
+		Distance:      p.Distance,
+		Interior:      p.Interior,
+	}
+}
+
+// bigTopLeft returns BigTopLeft or TopLeft if it is not set.
+func (p *Picture) bigTopLeft() BigComplex {
+	if p.BigTopLeft == nil {
+		return NewBigComplex(p.TopLeft, p.Precision)
+	}
+	return *p.BigTopLeft
+}
+
+// Calculate calculates all the areas with workerCount goroutines and sends the index of each area to doneIndex when
+// it is ready. doneIndex is closed at the end. Workers is used when workerCount is lower than 1, and a single
+// goroutine if neither is set.
+//
+// The areas that are still being calculated when ctx is cancelled are not marked complete, so the caller can stop
+// waiting for doneIndex and use the complete areas right away.
 func (p *Picture) Calculate(ctx context.Context, workerCount int, doneIndex chan<- int) {
-	wg := &sync.WaitGroup{}
-	wg.Add(workerCount)
+	p.calculating.Lock()
+	defer p.calculating.Unlock()
+	p.restart(ctx, workerCount, doneIndex)
+}
 
-	next := workQueue(ctx, len(p.areas))
+// restart calculates all the areas without waiting for a previous calculation.
+func (p *Picture) restart(ctx context.Context, workerCount int, doneIndex chan<- int) {
+	p.mutex.Lock()
+	for i := range p.complete {
+		p.complete[i] = false
+	}
+	p.mutex.Unlock()
+	p.calculate(ctx, workerCount, doneIndex)
+}
 
-	for worker := 0; worker < workerCount; worker++ {
-		go doWork(ctx, wg, p.areas, next, doneIndex)
+// Resume continues a calculation that was cancelled, only the areas that are not complete are calculated and sent
+// to doneIndex. doneIndex is closed at the end.
+func (p *Picture) Resume(ctx context.Context, workerCount int, doneIndex chan<- int) {
+	p.calculating.Lock()
+	defer p.calculating.Unlock()
+	p.calculate(ctx, workerCount, doneIndex)
+}
+
+// calculate calculates the areas that are not complete.
+func (p *Picture) calculate(ctx context.Context, workerCount int, doneIndex chan<- int) {
+	if workerCount < 1 {
+		workerCount = p.Workers
+	}
+	if workerCount < 1 {
+		workerCount = 1
+	}
+	mirrored, offset := p.mirrors()
+	// The points of the complete areas may have been restored elsewhere and not be available to mirror them.
+	if len(p.Missing()) < len(p.areas) {
+		mirrored = nil
+	}
+	work := make([]int, 0, len(p.areas))
+	for _, i := range p.areaOrder() {
+		if !p.complete[i] && (mirrored == nil || !mirrored[i]) {
+			work = append(work, i)
+		}
+	}
+
+	if p.Scheduler == StealingScheduler {
+		p.calculateStealing(ctx, work, workerCount, doneIndex)
+	} else {
+		wg := &sync.WaitGroup{}
+		wg.Add(workerCount)
+		next := workQueue(ctx, work)
+		for worker := 0; worker < workerCount; worker++ {
+			go p.doWork(ctx, wg, next, doneIndex)
+		}
+		wg.Wait()
 	}
 
-	wg.Wait()
+	for i := range mirrored {
+		if !mirrored[i] || p.complete[i] || ctx.Err() != nil {
+			continue
+		}
+		p.mirror(i, offset)
+		if !p.finish(ctx, i) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case doneIndex <- i:
+		}
+	}
 
 	close(doneIndex)
 }
 
+// finish marks the area i as complete unless it is in a pass of CalculateProgressive. It returns false if ctx is
+// cancelled, the area is not complete then because the caller may not be waiting for it anymore.
+func (p *Picture) finish(ctx context.Context, i int) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if ctx.Err() != nil {
+		return false
+	}
+	p.complete[i] = p.areas[i].step <= 1
+	return true
+}
+
+// Complete reports whether the area at the given index is completely calculated.
+func (p *Picture) Complete(index int) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.complete[index]
+}
+
+// Missing returns the indexes of the areas that are not complete, they are calculated by Resume.
+func (p *Picture) Missing() []int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var missing []int
+	for i, complete := range p.complete {
+		if !complete {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// MarkComplete marks the area at the given index as complete, so Resume does not calculate it. It is useful when the
+// result of the area is restored from a previous calculation.
+func (p *Picture) MarkComplete(index int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.complete[index] = true
+}
+
+// Deepen raises MaxIterations for the picture and all its areas and continues the calculation from the last computed orbit of each point.
+func (p *Picture) Deepen(ctx context.Context, maxIterations int, workerCount int, doneIndex chan<- int) {
+	p.MaxIterations = maxIterations
+	if p.reference != nil {
+		p.reference.Calculate(p.params())
+	}
+	for i := range p.areas {
+		p.areas[i].MaxIterations = maxIterations
+	}
+	p.Calculate(ctx, workerCount, doneIndex)
+}
+
 func (p *Picture) CalculateAsync(ctx context.Context, workerCount int) <-chan int {
 	doneIndex := make(chan int)
 	go p.Calculate(ctx, workerCount, doneIndex)
 	return doneIndex
 }
 
-func workQueue(ctx context.Context, workCount int) <-chan int {
+// ResumeAsync works like Resume and returns the channel that receives the index of each area.
+func (p *Picture) ResumeAsync(ctx context.Context, workerCount int) <-chan int {
+	doneIndex := make(chan int)
+	go p.Resume(ctx, workerCount, doneIndex)
+	return doneIndex
+}
+
+func workQueue(ctx context.Context, work []int) <-chan int {
 	next := make(chan int)
 	go func() {
-		for i := 0; i < workCount; i++ {
+		// next is closed even if the calculation is cancelled, so the workers can finish.
+		defer close(next)
+		for _, i := range work {
 			select {
 			case <-ctx.Done():
 				return
@@ -85,27 +375,67 @@ func workQueue(ctx context.Context, workCount int) <-chan int {
 
 			}
 		}
-		close(next)
 	}()
 	return next
 }
 
-func doWork(ctx context.Context, wg *sync.WaitGroup, areas []Area, next <-chan int, doneIndex chan<- int) {
+func (p *Picture) doWork(ctx context.Context, wg *sync.WaitGroup, next <-chan int, doneIndex chan<- int) {
 	defer wg.Done()
 	for i := range next {
 		areaRegion := trace.StartRegion(ctx, fmt.Sprintf("Area %d", i))
-		areas[i].Calculate()
+		p.areas[i].Calculate()
 		areaRegion.End()
-		doneIndex <- i
+		if !p.finish(ctx, i) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case doneIndex <- i:
+		}
 	}
 }
 
 func (p *Picture) HorizontalResolution() int {
-	return p.ChunkImageSize * p.HorizontalImageChunks
+	if p.ImageWidth > 0 {
+		return p.ImageWidth
+	}
+	return p.chunkImageWidth() * p.HorizontalImageChunks
 }
 
 func (p *Picture) VerticalResolution() int {
-	return p.ChunkImageSize * p.VerticalImageChunks
+	if p.ImageHeight > 0 {
+		return p.ImageHeight
+	}
+	return p.chunkImageHeight() * p.VerticalImageChunks
+}
+
+// areaResolution returns the resolution of the area x,y, that is smaller than the others if it is cut by the edges
+// of the image.
+func (p *Picture) areaResolution(x, y int) (width, height int) {
+	width, height = p.chunkImageWidth(), p.chunkImageHeight()
+	if right := p.HorizontalResolution() - x*width; right < width {
+		width = right
+	}
+	if bottom := p.VerticalResolution() - y*height; bottom < height {
+		height = bottom
+	}
+	return width, height
+}
+
+// chunkImageWidth returns ChunkImageWidth or ChunkImageSize if it is not set.
+func (p *Picture) chunkImageWidth() int {
+	if p.ChunkImageWidth == 0 {
+		return p.ChunkImageSize
+	}
+	return p.ChunkImageWidth
+}
+
+// chunkImageHeight returns ChunkImageHeight or ChunkImageSize if it is not set.
+func (p *Picture) chunkImageHeight() int {
+	if p.ChunkImageHeight == 0 {
+		return p.ChunkImageSize
+	}
+	return p.ChunkImageHeight
 }
 
 // IndexFor is an utility function to locate a x,y coordinate in the areas slice
@@ -122,7 +452,7 @@ func (p *Picture) ForIndex(i int) (x, y int) {
 
 func (p *Picture) GetImageOffsetFor(index int) (width, hight int) {
 	x, y := p.ForIndex(index)
-	return x * p.ChunkImageSize, y * p.ChunkImageSize
+	return x * p.chunkImageWidth(), y * p.chunkImageHeight()
 }
 
 func (p *Picture) GetArea(index int) Area {
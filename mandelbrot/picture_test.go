@@ -8,6 +8,8 @@ import (
 	"image/color"
 	"image/jpeg"
 	"log"
+	"math"
+	"math/cmplx"
 	"os"
 	"testing"
 
@@ -35,6 +37,313 @@ func TestSimpleRenderTrace(t *testing.T) {
 	}
 }
 
+func TestPictureDeepen(t *testing.T) {
+	newPicture := func(maxIterations int) *mandelbrot.Picture {
+		pic := &mandelbrot.Picture{
+			TopLeft:               complex(-1.401854499759, -0.000743603637),
+			MaxIterations:         maxIterations,
+			ChunkSize:             0.00021646,
+			HorizontalImageChunks: 3,
+			VerticalImageChunks:   3,
+			ChunkImageSize:        16,
+		}
+		pic.Init()
+		return pic
+	}
+	ctx := context.Background()
+
+	oneShot := newPicture(5000)
+	for range oneShot.CalculateAsync(ctx, 2) {
+	}
+
+	incremental := newPicture(50)
+	for range incremental.CalculateAsync(ctx, 2) {
+	}
+	for _, maxIterations := range []int{500, 5000} {
+		done := make(chan int)
+		go incremental.Deepen(ctx, maxIterations, 2, done)
+		for range done {
+		}
+	}
+
+	for i := 0; i < oneShot.HorizontalImageChunks*oneShot.VerticalImageChunks; i++ {
+		expected, got := oneShot.GetArea(i), incremental.GetArea(i)
+		if got.MaxIterations != 5000 {
+			t.Errorf("Area %d MaxIterations is %d", i, got.MaxIterations)
+		}
+		for j := range expected.Points {
+			if expected.Points[j] != got.Points[j] {
+				t.Errorf("Area %d point %d differs, one shot %d incremental %d", i, j, expected.Points[j].Iterations(), got.Points[j].Iterations())
+			}
+		}
+	}
+}
+
+func TestRectangularPicture(t *testing.T) {
+	pic, err := mandelbrot.NewRectangularPicture(complex(-2, 1), 3, 48, 27, 4, 3, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	if pic.HorizontalResolution() != 48 || pic.VerticalResolution() != 27 {
+		t.Fatalf("Expected a 48x27 image, got %dx%d", pic.HorizontalResolution(), pic.VerticalResolution())
+	}
+
+	pixelSize := 3.0 / 48
+	for i := 0; i < pic.HorizontalImageChunks*pic.VerticalImageChunks; i++ {
+		area := pic.GetArea(i)
+		offsetX, offsetY := pic.GetImageOffsetFor(i)
+		if area.HorizontalResolution != 12 || area.VerticalResolution != 9 {
+			t.Errorf("Area %d expected 12x9 points, got %dx%d", i, area.HorizontalResolution, area.VerticalResolution)
+		}
+		expected := complex(-2+float64(offsetX)*pixelSize, 1-float64(offsetY)*pixelSize)
+		if cmplx.Abs(area.TopLeft-expected) > 1e-12 {
+			t.Errorf("Area %d expected top left %f, got %f", i, expected, area.TopLeft)
+		}
+		span := area.BottomRight - area.TopLeft
+		if math.Abs(real(span)/12+imag(span)/9) > 1e-12 {
+			t.Errorf("Area %d pixels are not square, span %f", i, span)
+		}
+	}
+}
+
+func TestPictureUnevenAreas(t *testing.T) {
+	pic, err := mandelbrot.NewRectangularPicture(complex(-2, 1), 3, 100, 70, 3, 3, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	if pic.HorizontalResolution() != 100 || pic.VerticalResolution() != 70 {
+		t.Fatalf("Expected a 100x70 image, got %dx%d", pic.HorizontalResolution(), pic.VerticalResolution())
+	}
+
+	pixelSize := 3.0 / 100
+	covered := make([]int, 100*70)
+	for i := 0; i < pic.HorizontalImageChunks*pic.VerticalImageChunks; i++ {
+		area := pic.GetArea(i)
+		offsetX, offsetY := pic.GetImageOffsetFor(i)
+		for x := 0; x < area.HorizontalResolution; x++ {
+			for y := 0; y < area.VerticalResolution; y++ {
+				covered[offsetX+x+(offsetY+y)*100]++
+				expected := complex(-2+float64(offsetX+x)*pixelSize, 1-float64(offsetY+y)*pixelSize)
+				if got := area.GetPoint(x, y).Point; cmplx.Abs(got-expected) > 1e-12 {
+					t.Fatalf("Area %d point %d,%d expected %f got %f", i, x, y, expected, got)
+				}
+			}
+		}
+	}
+	for i, count := range covered {
+		if count != 1 {
+			t.Fatalf("Pixel %d is covered by %d areas", i, count)
+		}
+	}
+}
+
+func TestPictureFewerAreas(t *testing.T) {
+	pic, err := mandelbrot.NewRectangularPicture(complex(-2, 1), 3, 10, 20, 6, 3, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pic.HorizontalImageChunks != 5 || pic.VerticalImageChunks != 3 {
+		t.Errorf("Expected 5x3 areas, got %dx%d", pic.HorizontalImageChunks, pic.VerticalImageChunks)
+	}
+	pic.Init()
+	if pic.HorizontalResolution() != 10 || pic.VerticalResolution() != 20 {
+		t.Errorf("Expected a 10x20 image, got %dx%d", pic.HorizontalResolution(), pic.VerticalResolution())
+	}
+}
+
+func TestPictureInvalidParameters(t *testing.T) {
+	tests := []struct {
+		parameter     string
+		areaWidth     float64
+		width, height int
+		divisions     int
+		maxIterations int
+	}{
+		{"area width", 0, 100, 100, 2, 100},
+		{"area width", math.Inf(1), 100, 100, 2, 100},
+		{"image width", 3, 0, 100, 2, 100},
+		{"image height", 3, 100, -1, 2, 100},
+		{"horizontal divisions", 3, 100, 100, 0, 100},
+		{"horizontal divisions", 3, 10, 100, 11, 100},
+		{"vertical divisions", 3, 100, 10, 11, 100},
+		{"max iterations", 3, 100, 100, 2, -5},
+	}
+
+	for i, test := range tests {
+		pic, err := mandelbrot.NewRectangularPicture(complex(-2, 1), test.areaWidth, test.width, test.height, test.divisions, test.divisions, test.maxIterations)
+		parameterErr, ok := err.(*mandelbrot.ParameterError)
+		if pic != nil || !ok || parameterErr.Parameter != test.parameter {
+			t.Errorf("Test %d expected an error for %s, got %v", i, test.parameter, err)
+		}
+	}
+}
+
+func TestPictureNoWorkers(t *testing.T) {
+	pic, err := mandelbrot.NewPicture(complex(-2, 1.5), 3, 20, 2, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	done := 0
+	for range pic.CalculateAsync(context.Background(), 0) {
+		done++
+	}
+	if done != 4 {
+		t.Errorf("Expected 4 areas calculated, got %d", done)
+	}
+}
+
+func TestPictureResume(t *testing.T) {
+	newPicture := func() *mandelbrot.Picture {
+		pic, err := mandelbrot.NewPictureWithOptions(60, 60, mandelbrot.WithTileSize(10, 10), mandelbrot.WithMaxIterations(200))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pic.Init()
+		return pic
+	}
+	expected := newPicture()
+	for range expected.CalculateAsync(context.Background(), 2) {
+	}
+
+	got := newPicture()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	before := 0
+	for i := range got.CalculateAsync(ctx, 2) {
+		if !got.Complete(i) {
+			t.Errorf("Area %d sent before it is complete", i)
+		}
+		before++
+		if before == 3 {
+			cancel()
+		}
+	}
+	missing := got.Missing()
+	if len(missing) == 0 || len(missing) > 36-3 {
+		t.Fatalf("Expected missing areas after the cancellation, got %v", missing)
+	}
+
+	resumed := 0
+	for i := range got.ResumeAsync(context.Background(), 2) {
+		if contains(missing, i) {
+			resumed++
+		} else {
+			t.Errorf("Area %d calculated again", i)
+		}
+	}
+	if resumed != len(missing) || len(got.Missing()) != 0 {
+		t.Errorf("Expected %d areas resumed, got %d and still missing %v", len(missing), resumed, got.Missing())
+	}
+	for i := 0; i < 36; i++ {
+		expectedArea, gotArea := expected.GetArea(i), got.GetArea(i)
+		for j := range expectedArea.Points {
+			if expectedArea.Points[j] != gotArea.Points[j] {
+				t.Fatalf("Area %d point %d differs", i, j)
+			}
+		}
+	}
+}
+
+// blockingFormula signals started and waits for release on every iteration.
+type blockingFormula struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f blockingFormula) Iterate(z, c complex128) complex128 {
+	select {
+	case f.started <- struct{}{}:
+	default:
+	}
+	<-f.release
+	return z*z + c
+}
+
+func (f blockingFormula) Degree() float64 {
+	return 2
+}
+
+func (f blockingFormula) String() string {
+	return "blocking"
+}
+
+func TestPictureCancelBeingCalculated(t *testing.T) {
+	pic, err := mandelbrot.NewPicture(complex(-2, 1.5), 3, 10, 1, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	formula := blockingFormula{started: make(chan struct{}, 1), release: make(chan struct{})}
+	pic.Formula = formula
+	pic.Init()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	doneIndex := pic.CalculateAsync(ctx, 1)
+	<-formula.started
+	// The area finishes after the cancellation, the caller may have stopped waiting for it.
+	cancel()
+	close(formula.release)
+	for i := range doneIndex {
+		t.Errorf("Area %d sent after the cancellation", i)
+	}
+	if pic.Complete(0) {
+		t.Error("Expected the area that was being calculated not complete")
+	}
+}
+
+func TestPictureMarkComplete(t *testing.T) {
+	pic, err := mandelbrot.NewPicture(complex(-2, 1.5), 3, 40, 4, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	for _, i := range []int{0, 5, 15} {
+		pic.MarkComplete(i)
+	}
+	var calculated []int
+	for i := range pic.ResumeAsync(context.Background(), 2) {
+		calculated = append(calculated, i)
+	}
+	if len(calculated) != 13 || contains(calculated, 0) || contains(calculated, 5) || contains(calculated, 15) {
+		t.Errorf("Expected the areas not marked complete, got %v", calculated)
+	}
+}
+
+func contains(indexes []int, index int) bool {
+	for _, i := range indexes {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPictureRotation(t *testing.T) {
+	view := mandelbrot.View{Center: complex(-0.75, 0.1), Zoom: 20, Aspect: 2, Rotation: 0.7}
+	pic, err := view.Picture(60, 3, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	area := view.Area(60, 100)
+	area.Init()
+
+	for i := 0; i < pic.HorizontalImageChunks*pic.VerticalImageChunks; i++ {
+		chunk := pic.GetArea(i)
+		offsetX, offsetY := pic.GetImageOffsetFor(i)
+		for x := 0; x < chunk.HorizontalResolution; x++ {
+			for y := 0; y < chunk.VerticalResolution; y++ {
+				expected, got := area.GetPoint(offsetX+x, offsetY+y).Point, chunk.GetPoint(x, y).Point
+				if cmplx.Abs(expected-got) > 1e-12 {
+					t.Fatalf("Area %d point %d,%d expected %f got %f", i, x, y, expected, got)
+				}
+			}
+		}
+	}
+}
+
 func benchmarkComplexPictureWorkers(b *testing.B, workers int) {
 	for i := 0; i < b.N; i++ {
 		b.StopTimer()
@@ -71,6 +380,37 @@ func BenchmarkComplexPictureWorkers10(b *testing.B) { benchmarkComplexPictureWor
 func BenchmarkComplexPictureWorkers11(b *testing.B) { benchmarkComplexPictureWorkers(b, 11) }
 func BenchmarkComplexPictureWorkers12(b *testing.B) { benchmarkComplexPictureWorkers(b, 12) }
 
+// benchmarkScheduler calculates a picture of many small areas where a few of them, inside the set, are much more
+// expensive than the rest.
+func benchmarkScheduler(b *testing.B, scheduler mandelbrot.Scheduler, workers int) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		pic, err := mandelbrot.NewPictureWithOptions(400, 400,
+			mandelbrot.WithView(mandelbrot.View{Center: complex(-0.75, 0.2), Zoom: 4}),
+			mandelbrot.WithTileSize(8, 8),
+			mandelbrot.WithMaxIterations(5000),
+			mandelbrot.WithScheduler(scheduler),
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+		pic.Init()
+		b.StartTimer()
+
+		for range pic.CalculateAsync(context.Background(), workers) {
+		}
+	}
+}
+
+func BenchmarkSchedulerQueue4(b *testing.B) { benchmarkScheduler(b, mandelbrot.QueueScheduler, 4) }
+func BenchmarkSchedulerStealing4(b *testing.B) {
+	benchmarkScheduler(b, mandelbrot.StealingScheduler, 4)
+}
+func BenchmarkSchedulerQueue8(b *testing.B) { benchmarkScheduler(b, mandelbrot.QueueScheduler, 8) }
+func BenchmarkSchedulerStealing8(b *testing.B) {
+	benchmarkScheduler(b, mandelbrot.StealingScheduler, 8)
+}
+
 var saveImage = flag.Bool("saveImage", false, "save the result of running the benchmarks")
 
 func benchmarkComplexPictureChunks(b *testing.B, HorizontalImageChunks, VerticalImageChunks, ChunkImageSize, workers int) {
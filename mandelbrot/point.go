@@ -1,9 +1,53 @@
 package mandelbrot
 
+import (
+	"math"
+	"math/cmplx"
+)
+
+// DefaultBailout is the escape radius used when no other value is given.
+const DefaultBailout = 2.0
+
+// Params holds the settings used to iterate a Point.
+type Params struct {
+	MaxIterations int
+	// Bailout is the radius that a point must exceed to be considered escaped. DefaultBailout is used when it is zero.
+	Bailout float64
+	// Julia iterates the Julia set of C, each point is the starting value of z instead of the value of c.
+	Julia bool
+	C     complex128
+	// Formula is the function iterated, the Mandelbrot formula is used when it is nil.
+	Formula Formula
+	// Distance tracks the derivative of the orbit to estimate the distance from escaped points to the set.
+	// It requires a Formula that implements Differentiable.
+	Distance bool
+	// Interior stops the iteration of points that are proven to be inside the set. The main cardioid and the period 2
+	// bulb are detected without iterating for the Mandelbrot formula and periodic orbits are detected for any formula.
+	// It is ignored by the high precision and perturbation calculations.
+	Interior bool
+}
+
+// periodicityTolerance is the squared distance between two values of an orbit to consider it periodic.
+const periodicityTolerance = 1e-24
+
 // Point represents a single complex point and the iterations performed to check if belongs to mandelbrot set or not.
 type Point struct {
 	Point      complex128
 	iterations int
+	z          complex128
+	escaped    bool
+	smooth     float64
+	dz         complex128
+	distance   float64
+	interior   bool
+	period     int
+	// saved is the value of the orbit at the iteration savedAt, that is compared with the following values to detect
+	// periodic orbits. It is updated when the orbit reaches savedAt + interval and then the interval is doubled.
+	saved    complex128
+	savedAt  int
+	interval int
+	// filled points have the result of a neighbor instead of their own, see SubdivisionStrategy.
+	filled bool
 }
 
 // NewPoint returns a new point at a given coordinates
@@ -13,21 +57,232 @@ func NewPoint(r, i float64) Point {
 	}
 }
 
-// Calculate performs as many calculations as MaxIterations to determine if the point belongs to the set or not
+// Calculate performs as many calculations as MaxIterations to determine if the point belongs to the set or not.
+// The orbit is kept between calls, so calling it again with a higher MaxIterations continues from the last iteration.
 func (m *Point) Calculate(MaxIterations int) {
-	var z complex128
+	m.CalculateParams(Params{MaxIterations: MaxIterations})
+}
+
+// CalculateParams works like Calculate but allows to customize the iteration with Params.
+func (m *Point) CalculateParams(params Params) {
+	if m.filled {
+		*m = Point{Point: m.Point}
+	}
+	if m.interior {
+		m.setInterior(m.period, params.MaxIterations)
+		return
+	}
+	if m.escaped {
+		return
+	}
+	bailout := params.Bailout
+	if bailout == 0 {
+		bailout = DefaultBailout
+	}
+	bailoutSquared := bailout * bailout
+
+	z := m.z
 	point := m.Point
 	iterations := m.iterations
+	if params.Julia {
+		point = params.C
+		if iterations == 0 {
+			z = m.Point
+		}
+	}
 
-	for real(z)*real(z)+imag(z)*imag(z) < 4 && iterations < MaxIterations {
-		iterations++
-		z = z*z + point
+	mandelbrot := isMandelbrot(params.Formula)
+	if params.Interior && mandelbrot && !params.Julia {
+		if period := knownComponent(point); period > 0 {
+			m.setInterior(period, params.MaxIterations)
+			return
+		}
+	}
+
+	degree := 2.0
+	if mandelbrot && !params.Distance && !params.Interior {
+		for real(z)*real(z)+imag(z)*imag(z) < bailoutSquared && iterations < params.MaxIterations {
+			iterations++
+			z = z*z + point
+		}
+	} else {
+		formula := params.Formula
+		if mandelbrot {
+			formula = Multibrot{Power: 2}
+		}
+		degree = formula.Degree()
+		derivative := derivativeOf(formula, params)
+		dz, dc := m.dz, complex(1, 0)
+		if params.Julia {
+			dc = 0
+			if iterations == 0 {
+				dz = 1
+			}
+		}
+		saved, savedAt, interval := m.saved, m.savedAt, m.interval
+		if params.Interior && interval == 0 {
+			saved, savedAt, interval = z, iterations, 1
+		}
+		for real(z)*real(z)+imag(z)*imag(z) < bailoutSquared && iterations < params.MaxIterations {
+			iterations++
+			if derivative != nil {
+				dz = derivative(z, point)*dz + dc
+			}
+			if mandelbrot {
+				z = z*z + point
+			} else {
+				z = formula.Iterate(z, point)
+			}
+			if params.Interior {
+				// Brent's cycle detection, the orbit is compared with a saved value that is updated at increasing intervals.
+				if d := z - saved; real(d)*real(d)+imag(d)*imag(d) < periodicityTolerance {
+					m.z = z
+					m.setInterior(iterations-savedAt, params.MaxIterations)
+					return
+				}
+				if iterations-savedAt == interval {
+					saved, savedAt, interval = z, iterations, interval*2
+				}
+			}
+		}
+		if params.Interior {
+			m.saved, m.savedAt, m.interval = saved, savedAt, interval
+		}
+		m.dz = dz
+		if params.Distance {
+			m.distance = math.NaN()
+			if derivative != nil {
+				m.distance = distanceEstimation(z, dz)
+			}
+		}
 	}
 
 	m.iterations = iterations
+	m.z = z
+	if real(z)*real(z)+imag(z)*imag(z) >= bailoutSquared {
+		m.escaped = true
+		m.smooth = smoothIterations(iterations, z, degree)
+	}
+}
+
+// knownComponent returns the period of the main cardioid or the period 2 bulb of the Mandelbrot set if c belongs to
+// them, otherwise it returns 0.
+func knownComponent(c complex128) int {
+	x, y := real(c), imag(c)
+	q := (x-0.25)*(x-0.25) + y*y
+	if q*(q+(x-0.25)) <= y*y/4 {
+		return 1
+	}
+	if (x+1)*(x+1)+y*y <= 1.0/16 {
+		return 2
+	}
+	return 0
+}
+
+// setInterior marks the point as inside the set with the given period. The iterations are set to maxIterations, as if
+// the point had been iterated until the end.
+func (m *Point) setInterior(period int, maxIterations int) {
+	m.interior = true
+	m.period = period
+	if m.iterations < maxIterations {
+		m.iterations = maxIterations
+	}
+}
+
+// filledAt returns a point at the given coordinates with the result of m. The orbit is not copied, so the point is
+// calculated from the start if it is ever resumed.
+func (m *Point) filledAt(point complex128) Point {
+	return Point{
+		Point:      point,
+		iterations: m.iterations,
+		escaped:    m.escaped,
+		smooth:     m.smooth,
+		distance:   m.distance,
+		interior:   m.interior,
+		period:     m.period,
+		filled:     true,
+	}
+}
+
+// conjugate returns the conjugate of m with the conjugate of its orbit. For formulas that are ConjugateSymmetric, it is
+// exactly the same as calculating the conjugate point.
+func (m *Point) conjugate() Point {
+	conjugate := *m
+	conjugate.Point = complex(real(m.Point), -imag(m.Point))
+	conjugate.z = complex(real(m.z), -imag(m.z))
+	conjugate.dz = complex(real(m.dz), -imag(m.dz))
+	conjugate.saved = complex(real(m.saved), -imag(m.saved))
+	return conjugate
+}
+
+// derivativeOf returns the derivative of the formula if the distance is requested and the formula is Differentiable.
+func derivativeOf(formula Formula, params Params) func(z, c complex128) complex128 {
+	if !params.Distance {
+		return nil
+	}
+	differentiable, ok := formula.(Differentiable)
+	if !ok {
+		return nil
+	}
+	return differentiable.Derivative
+}
+
+// distanceEstimation returns the estimated distance to the set of an escaped orbit from its last value and derivative.
+// It is the potential of the orbit, ln|z|/d^n, divided by the norm of its gradient, |dz|/(|z|*d^n). The degree d cancels
+// out, so there is no factor for the degree of the formula.
+func distanceEstimation(z, dz complex128) float64 {
+	abs := cmplx.Abs(z)
+	return 2 * abs * math.Log(abs) / cmplx.Abs(dz)
+}
+
+// smoothIterations gives the continuous iteration count of an orbit that escaped at the given iteration for a formula of the given degree.
+func smoothIterations(iterations int, z complex128, degree float64) float64 {
+	logZ := math.Log(real(z)*real(z)+imag(z)*imag(z)) / 2
+	return float64(iterations) + 1 - math.Log(logZ)/math.Log(degree)
 }
 
 // Iterations returns the number of performed iterations.
 func (m *Point) Iterations() int {
 	return m.iterations
 }
+
+// Escaped reports whether the orbit has exceeded the bailout radius.
+func (m *Point) Escaped() bool {
+	return m.escaped
+}
+
+// SmoothIterations returns a continuous version of Iterations for escaped points, it removes the bands produced by integer counts.
+// Points that did not escape return Iterations.
+func (m *Point) SmoothIterations() float64 {
+	if !m.escaped {
+		return float64(m.iterations)
+	}
+	return m.smooth
+}
+
+// Distance returns the estimated distance from an escaped point to the set, it is 0 for points that did not escape.
+// It is only calculated with Params.Distance and it is NaN if the formula does not implement Differentiable.
+// The real distance is between a quarter of the estimation and, approximately, the estimation itself. These bounds are
+// only proven for the degree 2 Mandelbrot and Julia sets. For other formulas the estimation has the same scale, but it
+// is only an approximation.
+func (m *Point) Distance() float64 {
+	if !m.escaped {
+		return 0
+	}
+	return m.distance
+}
+
+// Interior reports whether the point has been proven to be inside the set, see Params.Interior.
+func (m *Point) Interior() bool {
+	return m.interior
+}
+
+// Period returns the period of the orbit of interior points, it is 0 if the point is not known to be interior.
+func (m *Point) Period() int {
+	return m.period
+}
+
+// Z returns the last value of the orbit.
+func (m *Point) Z() complex128 {
+	return m.z
+}
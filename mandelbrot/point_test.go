@@ -1,6 +1,7 @@
 package mandelbrot_test
 
 import (
+	"math"
 	"testing"
 
 	. "github.com/metalblueberry/mandelbrot/mandelbrot"
@@ -46,6 +47,167 @@ func TestMandelbrotPoint(t *testing.T) {
 	}
 }
 
+func TestMandelbrotPointResume(t *testing.T) {
+	points := []Point{
+		NewPoint(1, 0),
+		NewPoint(-1, 0),
+		NewPoint(-0.75, 0.1),
+		NewPoint(0.3, 0.5),
+		NewPoint(-0.1011, 0.9563),
+	}
+
+	for i, point := range points {
+		oneShot := point
+		oneShot.Calculate(10000)
+
+		incremental := point
+		for _, maxIterations := range []int{100, 1000, 10000} {
+			incremental.Calculate(maxIterations)
+		}
+
+		if oneShot.Iterations() != incremental.Iterations() || oneShot.Z() != incremental.Z() {
+			t.Errorf("Test %d failed, Point %f one shot %d %v incremental %d %v", i, point.Point, oneShot.Iterations(), oneShot.Z(), incremental.Iterations(), incremental.Z())
+		}
+	}
+}
+
+func TestMandelbrotPointSmoothIterations(t *testing.T) {
+	params := Params{MaxIterations: 1000, Bailout: 1000}
+	previous := NewPoint(0.3, 0)
+	previous.CalculateParams(params)
+	for r := 0.3001; r < 0.6; r += 0.0001 {
+		point := NewPoint(r, 0)
+		point.CalculateParams(params)
+		if !point.Escaped() {
+			t.Fatalf("Point %f did not escape", point.Point)
+		}
+		if diff := previous.SmoothIterations() - point.SmoothIterations(); diff < 0 || diff > 0.5 {
+			t.Errorf("Point %f smooth iterations jump from %f to %f", point.Point, previous.SmoothIterations(), point.SmoothIterations())
+		}
+		previous = point
+	}
+}
+
+func TestMandelbrotPointBailout(t *testing.T) {
+	small := NewPoint(0.5, 0.5)
+	small.CalculateParams(Params{MaxIterations: 100, Bailout: 2})
+	large := NewPoint(0.5, 0.5)
+	large.CalculateParams(Params{MaxIterations: 100, Bailout: 1e6})
+	if !small.Escaped() || !large.Escaped() {
+		t.Fatalf("Points must escape")
+	}
+	if large.Iterations() <= small.Iterations() {
+		t.Errorf("Larger bailout must take more iterations, got %d and %d", small.Iterations(), large.Iterations())
+	}
+	if abs := real(large.Z())*real(large.Z()) + imag(large.Z())*imag(large.Z()); abs < 1e12 {
+		t.Errorf("Final |z|^2 %f is below the bailout", abs)
+	}
+}
+
+func TestJuliaPoint(t *testing.T) {
+	// The Julia set of 0 is the unit disk.
+	params := Params{MaxIterations: 100, Julia: true, C: 0}
+	tests := []testMandelbrotPointCases{
+		testMandelbrotPointCases{
+			point:    NewPoint(0.5, 0.5),
+			diverges: false,
+		},
+		testMandelbrotPointCases{
+			point:    NewPoint(-0.9, 0),
+			diverges: false,
+		},
+		testMandelbrotPointCases{
+			point:    NewPoint(1.1, 0),
+			diverges: true,
+		},
+		testMandelbrotPointCases{
+			point:    NewPoint(0, -1.01),
+			diverges: true,
+		},
+	}
+
+	for i, test := range tests {
+		test.point.CalculateParams(params)
+		if test.point.Escaped() != test.diverges {
+			t.Errorf("Test %d failed, Point %f diverges %t expected %t", i, test.point.Point, test.point.Escaped(), test.diverges)
+		}
+	}
+}
+
+func TestMandelbrotPointDistance(t *testing.T) {
+	tests := []struct {
+		params   Params
+		point    Point
+		distance float64
+	}{
+		{Params{MaxIterations: 1000, Bailout: 1000, Distance: true}, NewPoint(0.5, 0), 0.25},
+		{Params{MaxIterations: 1000, Bailout: 1000, Distance: true}, NewPoint(-2.5, 0), 0.5},
+		{Params{MaxIterations: 1000, Bailout: 1000, Distance: true, Formula: Multibrot{Power: 3}}, NewPoint(0, 1.5), 0.5},
+		{Params{MaxIterations: 1000, Bailout: 1000, Distance: true, Julia: true}, NewPoint(2, 0), 1},
+		{Params{MaxIterations: 1000, Bailout: 1000, Distance: true}, NewPoint(-1, 0), 0},
+	}
+
+	for i, test := range tests {
+		test.point.CalculateParams(test.params)
+		distance := test.point.Distance()
+		if distance < test.distance*0.9 || distance > test.distance*4 {
+			t.Errorf("Test %d failed, Point %f distance %f expected %f", i, test.point.Point, distance, test.distance)
+		}
+	}
+
+	point := NewPoint(0.5, 0)
+	point.CalculateParams(Params{MaxIterations: 1000, Distance: true, Formula: BurningShip{}})
+	if !math.IsNaN(point.Distance()) {
+		t.Errorf("Distance is not available for BurningShip, got %f", point.Distance())
+	}
+}
+
+func TestMandelbrotPointInterior(t *testing.T) {
+	tests := []struct {
+		params   Params
+		point    Point
+		interior bool
+		period   int
+	}{
+		{Params{MaxIterations: 1000, Interior: true}, NewPoint(0, 0), true, 1},
+		{Params{MaxIterations: 1000, Interior: true}, NewPoint(-0.5, 0.5), true, 1},
+		{Params{MaxIterations: 1000, Interior: true}, NewPoint(-1, 0), true, 2},
+		{Params{MaxIterations: 1000, Interior: true}, NewPoint(-0.1225, 0.7449), true, 3},
+		{Params{MaxIterations: 1000, Interior: true}, NewPoint(-1.3107, 0), true, 4},
+		{Params{MaxIterations: 1000, Interior: true}, NewPoint(-0.75, 0.1), false, 0},
+		{Params{MaxIterations: 1000, Interior: true}, NewPoint(1, 0), false, 0},
+		{Params{MaxIterations: 1000, Interior: true, Formula: Multibrot{Power: 3}}, NewPoint(0, 0), true, 1},
+		{Params{MaxIterations: 1000, Interior: true, Julia: true, C: -1}, NewPoint(0, 0), true, 2},
+		{Params{MaxIterations: 1000}, NewPoint(0, 0), false, 0},
+	}
+
+	for i, test := range tests {
+		test.point.CalculateParams(test.params)
+		if test.point.Interior() != test.interior || test.point.Period() != test.period {
+			t.Errorf("Test %d failed, Point %f interior %t with period %d, expected %t with period %d", i, test.point.Point, test.point.Interior(), test.point.Period(), test.interior, test.period)
+		}
+		if test.point.Interior() && test.point.Iterations() != test.params.MaxIterations {
+			t.Errorf("Test %d failed, interior Point %f must report %d iterations, got %d", i, test.point.Point, test.params.MaxIterations, test.point.Iterations())
+		}
+	}
+}
+
+func TestMandelbrotPointInteriorMatchesIterations(t *testing.T) {
+	for i := 0; i < 250; i++ {
+		for j := 0; j < 120; j++ {
+			x, y := -2+float64(i)/99.7, float64(j)/99.7
+			plain := NewPoint(x, y)
+			plain.Calculate(500)
+			detected := NewPoint(x, y)
+			detected.CalculateParams(Params{MaxIterations: 200, Interior: true})
+			detected.CalculateParams(Params{MaxIterations: 500, Interior: true})
+			if plain.Iterations() != detected.Iterations() {
+				t.Errorf("Point %f expected %d iterations, got %d", plain.Point, plain.Iterations(), detected.Iterations())
+			}
+		}
+	}
+}
+
 var iterations int
 
 func BenchmarkCalculate(b *testing.B) {
@@ -56,3 +218,12 @@ func BenchmarkCalculate(b *testing.B) {
 	}
 	iterations = point.Iterations()
 }
+
+func BenchmarkCalculateInterior(b *testing.B) {
+	var point Point
+	for i := 0; i < b.N; i++ {
+		point := NewPoint(-0.1225, 0.7449)
+		point.CalculateParams(Params{MaxIterations: 3000, Interior: true})
+	}
+	iterations = point.Iterations()
+}
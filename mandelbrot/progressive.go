@@ -0,0 +1,83 @@
+package mandelbrot
+
+import "context"
+
+// CalculateProgressive calculates the picture in several passes, so a coarse image is available quickly. The first
+// pass calculates one pixel of every step x step block of each area and gives its result to the whole block, then
+// every pass halves the step until all the pixels are calculated. The pixels of previous passes are not calculated
+// again. step is rounded up to a power of two.
+//
+// passDone is called with the step of each pass when all the areas have completed it, the last one is 1 and it gives
+// the same result as Calculate. The next pass does not start until passDone returns, so it can read the areas to show
+// the intermediate result. High precision pictures are calculated in a single pass.
+//
+// When ctx is cancelled it returns without waiting for the areas of the pass that are being calculated, like
+// Calculate. The next calculation of the picture waits for them.
+func (p *Picture) CalculateProgressive(ctx context.Context, workerCount int, step int, passDone func(step int)) {
+	p.calculating.Lock()
+	pass := 1
+	for pass < step && p.Precision == 0 && !p.Perturbation {
+		pass *= 2
+	}
+	for ; pass >= 1; pass /= 2 {
+		p.setStep(pass)
+		doneIndex := make(chan int)
+		go p.restart(ctx, workerCount, doneIndex)
+		if !waitPass(ctx, doneIndex) {
+			go func() {
+				for range doneIndex {
+				}
+				p.finishProgressive()
+			}()
+			return
+		}
+		passDone(pass)
+	}
+	p.finishProgressive()
+}
+
+// waitPass waits until every area of the pass is calculated, it returns false if ctx is cancelled before.
+func waitPass(ctx context.Context, doneIndex <-chan int) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case _, ok := <-doneIndex:
+			if !ok {
+				return ctx.Err() == nil
+			}
+		}
+	}
+}
+
+// finishProgressive leaves the areas ready to calculate all their points and lets other calculations start.
+func (p *Picture) finishProgressive() {
+	p.setStep(0)
+	p.calculating.Unlock()
+}
+
+// setStep sets the step of the pass that the areas calculate, see Area.calculatePass.
+func (p *Picture) setStep(step int) {
+	for i := range p.areas {
+		p.areas[i].step = step
+	}
+}
+
+// calculatePass calculates the points whose coordinates are multiples of step and fills the rest of their
+// step x step block with their result.
+func (a *Area) calculatePass(params Params) {
+	for y := 0; y < a.VerticalResolution; y += a.step {
+		for x := 0; x < a.HorizontalResolution; x += a.step {
+			i := a.IndexFor(x, y)
+			a.Points[i].CalculateParams(params)
+			for by := y; by < y+a.step && by < a.VerticalResolution; by++ {
+				for bx := x; bx < x+a.step && bx < a.HorizontalResolution; bx++ {
+					if bx != x || by != y {
+						j := a.IndexFor(bx, by)
+						a.Points[j] = a.Points[i].filledAt(a.Points[j].Point)
+					}
+				}
+			}
+		}
+	}
+}
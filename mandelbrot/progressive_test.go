@@ -0,0 +1,124 @@
+package mandelbrot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+func newProgressivePicture(t *testing.T, iterations *int64) *Picture {
+	pic, err := NewPictureWithOptions(90, 60,
+		WithView(View{Center: complex(-0.75, 0.1), Zoom: 4}),
+		WithTileSize(30, 30),
+		WithMaxIterations(200),
+		WithFormula(countingFormula{iterations: iterations}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	return pic
+}
+
+func TestPictureProgressive(t *testing.T) {
+	var expectedIterations, gotIterations int64
+	expected := newProgressivePicture(t, &expectedIterations)
+	for range expected.CalculateAsync(context.Background(), 2) {
+	}
+
+	got := newProgressivePicture(t, &gotIterations)
+	var passes []int
+	got.CalculateProgressive(context.Background(), 2, 6, func(step int) {
+		passes = append(passes, step)
+		if step != 8 {
+			return
+		}
+		area := got.GetArea(0)
+		for _, point := range [][2]int{{3, 5}, {7, 7}, {12, 9}} {
+			x, y := point[0], point[1]
+			if area.Points[area.IndexFor(x, y)].Iterations() != area.Points[area.IndexFor(x-x%8, y-y%8)].Iterations() {
+				t.Errorf("Pixel %d,%d expected the result of its block", x, y)
+			}
+		}
+	})
+
+	if len(passes) != 4 || passes[0] != 8 || passes[3] != 1 {
+		t.Errorf("Expected passes 8, 4, 2 and 1, got %v", passes)
+	}
+	assertSamePoints(t, expected, got)
+	if gotIterations != expectedIterations {
+		t.Errorf("Expected %d iterations, the progressive calculation took %d", expectedIterations, gotIterations)
+	}
+}
+
+func TestPictureProgressiveCancel(t *testing.T) {
+	var iterations int64
+	expected := newProgressivePicture(t, &iterations)
+	for range expected.CalculateAsync(context.Background(), 2) {
+	}
+
+	pic := newProgressivePicture(t, &iterations)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var passes []int
+	pic.CalculateProgressive(ctx, 2, 8, func(step int) {
+		passes = append(passes, step)
+		cancel()
+	})
+	if len(passes) != 1 {
+		t.Errorf("Expected a single pass, got %v", passes)
+	}
+
+	// The areas calculate all their points again after a progressive calculation.
+	for range pic.CalculateAsync(context.Background(), 2) {
+	}
+	assertSamePoints(t, expected, pic)
+}
+
+func TestPictureProgressiveCancelBeingCalculated(t *testing.T) {
+	pic, err := NewPicture(complex(-2, 1.5), 3, 16, 1, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	formula := blockingFormula{started: make(chan struct{}, 1), release: make(chan struct{})}
+	pic.Formula = formula
+	pic.Init()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		pic.CalculateProgressive(ctx, 1, 4, func(step int) {
+			t.Errorf("Pass %d done after the cancellation", step)
+		})
+	}()
+	<-formula.started
+	cancel()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected to return without waiting for the area that is being calculated")
+	}
+
+	// The next calculation waits for the pass and calculates all the points.
+	close(formula.release)
+	for range pic.CalculateAsync(context.Background(), 1) {
+	}
+	if !pic.Complete(0) {
+		t.Error("Expected the area complete after the calculation")
+	}
+}
+
+func assertSamePoints(t *testing.T, expected, got *Picture) {
+	t.Helper()
+	for i := 0; i < got.HorizontalImageChunks*got.VerticalImageChunks; i++ {
+		expectedArea, gotArea := expected.GetArea(i), got.GetArea(i)
+		for j := range expectedArea.Points {
+			if expectedArea.Points[j] != gotArea.Points[j] {
+				t.Fatalf("Area %d point %d differs", i, j)
+			}
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package mandelbrot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// The raw format keeps the result of every pixel of a picture so it can be colored again without calculating it.
+// All the numbers are little endian.
+//
+// The header is never compressed:
+//
+//	magic          4 bytes   "MBR" followed by the version of the format, currently 1
+//	flags          uint8     1: the body is compressed with gzip
+//	                         2: the distance is calculated, see Picture.Distance
+//	                         4: the interior points are detected, see Picture.Interior
+//	                         8: the Julia set of C is drawn instead of the Mandelbrot set
+//	width          uint32    pixels of each row
+//	height         uint32    rows
+//	max iterations uint32
+//	bailout        float64
+//	precision      uint32    bits used to calculate the picture, 0 for float64
+//	top left       2 strings real and imaginary parts of the corner of the picture before the rotation, in decimal
+//	pixel size     float64   distance between two consecutive pixels in the complex plane
+//	rotation       float64   radians turned counterclockwise around the center of the picture
+//	c              float64   real and imaginary parts of the Julia set parameter
+//	               float64
+//	formula        string    name of the formula, see ParseFormula
+//
+// The strings are a uint16 with the length in bytes followed by the text.
+//
+// The body has the pixels row by row from the top left corner. Each pixel is a uint16 with the number of samples
+// followed by the samples, the first one is the pixel itself, see Area.GetSample. Each sample is:
+//
+//	iterations     uint32    see Point.Iterations
+//	state          uint8     1: escaped, 2: interior
+//	period         uint32    see Point.Period
+//	smooth         float64   see Point.SmoothIterations
+//	distance       float64   see Point.Distance
+
+// rawVersion changes every time the raw format changes, old files can't be loaded.
+const rawVersion = 1
+
+const (
+	rawCompressed = 1 << iota
+	rawDistance
+	rawInterior
+	rawJulia
+)
+
+const (
+	rawEscaped = 1 << iota
+	rawInteriorPoint
+)
+
+// rawSampleSize is the size in bytes of each sample in the body.
+const rawSampleSize = 4 + 1 + 4 + 8 + 8
+
+var rawMagic = [3]byte{'M', 'B', 'R'}
+
+// rawHeader is the fixed size part of the header after the magic.
+type rawHeader struct {
+	Flags         uint8
+	Width         uint32
+	Height        uint32
+	MaxIterations uint32
+	Bailout       float64
+	Precision     uint32
+}
+
+// SaveRaw writes the result of every pixel of the picture in the raw format to w, the body is compressed when
+// compress is true. The areas that are not complete are written as they are. LoadRaw reads it back.
+func (p *Picture) SaveRaw(w io.Writer, compress bool) error {
+	formula := ""
+	if p.Formula != nil {
+		if _, err := ParseFormula(p.Formula.String()); err != nil {
+			return fmt.Errorf("the formula %s can't be saved, cause: %s", p.Formula, err)
+		}
+		formula = p.Formula.String()
+	}
+
+	header := rawHeader{
+		Width:         uint32(p.HorizontalResolution()),
+		Height:        uint32(p.VerticalResolution()),
+		MaxIterations: uint32(p.MaxIterations),
+		Bailout:       p.Bailout,
+		Precision:     uint32(p.Precision),
+	}
+	if compress {
+		header.Flags |= rawCompressed
+	}
+	if p.Distance {
+		header.Flags |= rawDistance
+	}
+	if p.Interior {
+		header.Flags |= rawInterior
+	}
+	if p.Julia {
+		header.Flags |= rawJulia
+	}
+	re, im := strconv.FormatFloat(real(p.TopLeft), 'g', -1, 64), strconv.FormatFloat(imag(p.TopLeft), 'g', -1, 64)
+	if p.BigTopLeft != nil {
+		re, im = p.BigTopLeft.Real.Text('g', -1), p.BigTopLeft.Imag.Text('g', -1)
+	}
+
+	// The errors of out are kept until it is flushed, only the length of the strings is checked while writing.
+	out := bufio.NewWriter(w)
+	out.Write(rawMagic[:])
+	out.WriteByte(rawVersion)
+	binary.Write(out, binary.LittleEndian, header)
+	for _, s := range []string{re, im} {
+		if err := writeRawString(out, s); err != nil {
+			return err
+		}
+	}
+	binary.Write(out, binary.LittleEndian, []float64{p.ChunkSize / float64(p.chunkImageWidth()), p.Rotation, real(p.C), imag(p.C)})
+	if err := writeRawString(out, formula); err != nil {
+		return err
+	}
+
+	var body io.Writer = out
+	var compressor *gzip.Writer
+	if compress {
+		compressor = gzip.NewWriter(out)
+		body = compressor
+	}
+	if err := p.saveRawPixels(body); err != nil {
+		return err
+	}
+	if compressor != nil {
+		if err := compressor.Close(); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// saveRawPixels writes the body of the raw format.
+func (p *Picture) saveRawPixels(w io.Writer) error {
+	width, height := p.chunkImageWidth(), p.chunkImageHeight()
+	buffer := make([]byte, rawSampleSize)
+	for y := 0; y < p.VerticalResolution(); y++ {
+		for x := 0; x < p.HorizontalResolution(); x++ {
+			area := &p.areas[p.IndexFor(x/width, y/height)]
+			samples := area.SampleCount(x%width, y%height)
+			if samples > math.MaxUint16 {
+				return fmt.Errorf("the pixel %d,%d has %d samples, the maximum is %d", x, y, samples, math.MaxUint16)
+			}
+			binary.LittleEndian.PutUint16(buffer, uint16(samples))
+			if _, err := w.Write(buffer[:2]); err != nil {
+				return err
+			}
+			for s := 0; s < samples; s++ {
+				point := area.GetSample(x%width, y%height, s)
+				encodeRawSample(buffer, &point)
+				if _, err := w.Write(buffer); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// LoadRaw reads a picture written by SaveRaw. The picture has a single complete area with the points of every pixel,
+// so it can be colored like the original one, and its parameters are the ones in the header.
+func LoadRaw(r io.Reader) (*Picture, error) {
+	in := bufio.NewReader(r)
+	var magic [4]byte
+	if _, err := io.ReadFull(in, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic[0] != rawMagic[0] || magic[1] != rawMagic[1] || magic[2] != rawMagic[2] {
+		return nil, errors.New("it is not a raw mandelbrot file")
+	}
+	if magic[3] != rawVersion {
+		return nil, fmt.Errorf("the raw version %d is not supported", magic[3])
+	}
+
+	var header rawHeader
+	if err := binary.Read(in, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	re, err := readRawString(in)
+	if err != nil {
+		return nil, err
+	}
+	im, err := readRawString(in)
+	if err != nil {
+		return nil, err
+	}
+	view := make([]float64, 4)
+	if err := binary.Read(in, binary.LittleEndian, view); err != nil {
+		return nil, err
+	}
+	formulaName, err := readRawString(in)
+	if err != nil {
+		return nil, err
+	}
+
+	precision := uint(header.Precision)
+	if precision < 53 {
+		precision = 53
+	}
+	topLeft, err := ParseBigComplex(re, im, precision)
+	if err != nil {
+		return nil, fmt.Errorf("invalid top left corner, cause: %s", err)
+	}
+	pixelSize, rotation := view[0], view[1]
+	width, height := int(header.Width), int(header.Height)
+	// The picture is a single area, so it is not larger than a slice can index.
+	if width <= 0 || height <= 0 || uint64(width)*uint64(height) > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid size %dx%d", header.Width, header.Height)
+	}
+
+	// The pixels are read before building the picture, so the memory is only allocated for the pixels that are in
+	// the file and not for the size in the header.
+	var body io.Reader = in
+	if header.Flags&rawCompressed != 0 {
+		decompressor, err := gzip.NewReader(in)
+		if err != nil {
+			return nil, err
+		}
+		defer decompressor.Close()
+		body = decompressor
+	}
+	points, samples, err := readRawPixels(body, width*height)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pixels, cause: %s", err)
+	}
+
+	p, err := NewRectangularPicture(topLeft.Complex128(), pixelSize*float64(width), width, height, 1, 1, int(header.MaxIterations))
+	if err != nil {
+		return nil, err
+	}
+	if header.Precision > 0 {
+		p.BigTopLeft = &topLeft
+	}
+	p.Rotation = rotation
+	p.Bailout = header.Bailout
+	p.Julia = header.Flags&rawJulia != 0
+	p.C = complex(view[2], view[3])
+	p.Distance = header.Flags&rawDistance != 0
+	p.Interior = header.Flags&rawInterior != 0
+	if formulaName != "" {
+		if p.Formula, err = ParseFormula(formulaName); err != nil {
+			return nil, err
+		}
+	}
+	p.Init()
+	// The Precision is set after Init because the points are not calculated again.
+	p.Precision = uint(header.Precision)
+
+	area := &p.areas[0]
+	if p.BigTopLeft != nil {
+		// The area covers the whole picture, so its corners are not moved by the rotation. The high precision corners
+		// give the pixel size at zooms where the float64 corners are the same number.
+		bottomRight := topLeft.Add(complex(p.ChunkSize, -p.ChunkSize*float64(height)/float64(width)))
+		area.Precision, area.BigTopLeft, area.BigBottomRight = p.Precision, &topLeft, &bottomRight
+		area.initSpan()
+	}
+	for i := range points {
+		points[i].Point = area.Points[i].Point
+		if samples == nil {
+			continue
+		}
+		for j := range samples[i] {
+			samples[i][j].Point = area.Points[i].Point
+		}
+	}
+	area.Points, area.samples = points, samples
+	p.complete[0] = true
+	return p, nil
+}
+
+// readRawPixels reads count pixels from the body of the raw format. The samples are nil if no pixel has more than one
+// sample, see Area.GetSample.
+func readRawPixels(r io.Reader, count int) ([]Point, [][]Point, error) {
+	var points []Point
+	var samples [][]Point
+	buffer := make([]byte, rawSampleSize)
+	for i := 0; i < count; i++ {
+		if _, err := io.ReadFull(r, buffer[:2]); err != nil {
+			return nil, nil, err
+		}
+		sampleCount := int(binary.LittleEndian.Uint16(buffer))
+		if sampleCount == 0 {
+			return nil, nil, fmt.Errorf("the pixel %d has no samples", i)
+		}
+		if sampleCount > 1 && samples == nil {
+			samples = make([][]Point, i, cap(points))
+		}
+		var pixelSamples []Point
+		for s := 0; s < sampleCount; s++ {
+			if _, err := io.ReadFull(r, buffer); err != nil {
+				return nil, nil, err
+			}
+			var point Point
+			decodeRawSample(buffer, &point)
+			if s == 0 {
+				points = append(points, point)
+				continue
+			}
+			pixelSamples = append(pixelSamples, point)
+		}
+		if samples != nil {
+			samples = append(samples, pixelSamples)
+		}
+	}
+	return points, samples, nil
+}
+
+func encodeRawSample(buffer []byte, point *Point) {
+	state := uint8(0)
+	if point.Escaped() {
+		state |= rawEscaped
+	}
+	if point.Interior() {
+		state |= rawInteriorPoint
+	}
+	binary.LittleEndian.PutUint32(buffer[0:], uint32(point.Iterations()))
+	buffer[4] = state
+	binary.LittleEndian.PutUint32(buffer[5:], uint32(point.Period()))
+	binary.LittleEndian.PutUint64(buffer[9:], math.Float64bits(point.SmoothIterations()))
+	binary.LittleEndian.PutUint64(buffer[17:], math.Float64bits(point.Distance()))
+}
+
+func decodeRawSample(buffer []byte, point *Point) {
+	point.iterations = int(binary.LittleEndian.Uint32(buffer[0:]))
+	point.escaped = buffer[4]&rawEscaped != 0
+	point.interior = buffer[4]&rawInteriorPoint != 0
+	point.period = int(binary.LittleEndian.Uint32(buffer[5:]))
+	point.smooth = math.Float64frombits(binary.LittleEndian.Uint64(buffer[9:]))
+	point.distance = math.Float64frombits(binary.LittleEndian.Uint64(buffer[17:]))
+}
+
+func writeRawString(w io.Writer, s string) error {
+	if len(s) > math.MaxUint16 {
+		return fmt.Errorf("the text %q is too long", s)
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint16(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+func readRawString(r io.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return "", err
+	}
+	s := make([]byte, length)
+	if _, err := io.ReadFull(r, s); err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
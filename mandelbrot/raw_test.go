@@ -0,0 +1,184 @@
+package mandelbrot_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"runtime"
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+// rawRoundTrip calculates the picture and loads it back from its raw data.
+func rawRoundTrip(t *testing.T, pic *Picture, compress bool) *Picture {
+	t.Helper()
+	for range pic.CalculateAsync(context.Background(), 2) {
+	}
+	buffer := &bytes.Buffer{}
+	if err := pic.SaveRaw(buffer, compress); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadRaw(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.HorizontalResolution() != pic.HorizontalResolution() || loaded.VerticalResolution() != pic.VerticalResolution() {
+		t.Fatalf("Expected size %dx%d, got %dx%d", pic.HorizontalResolution(), pic.VerticalResolution(), loaded.HorizontalResolution(), loaded.VerticalResolution())
+	}
+	if !loaded.Complete(0) {
+		t.Error("Expected a complete picture")
+	}
+	return loaded
+}
+
+// assertSamePixels checks that every pixel of the loaded picture has the results of the original one.
+func assertSamePixels(t *testing.T, expected, loaded *Picture) {
+	t.Helper()
+	area := loaded.GetArea(0)
+	for i := 0; i < expected.HorizontalImageChunks*expected.VerticalImageChunks; i++ {
+		expectedArea := expected.GetArea(i)
+		offsetX, offsetY := expected.GetImageOffsetFor(i)
+		for x := 0; x < expectedArea.HorizontalResolution; x++ {
+			for y := 0; y < expectedArea.VerticalResolution; y++ {
+				samples := expectedArea.SampleCount(x, y)
+				if got := area.SampleCount(offsetX+x, offsetY+y); got != samples {
+					t.Fatalf("Pixel %d,%d expected %d samples, got %d", offsetX+x, offsetY+y, samples, got)
+				}
+				for s := 0; s < samples; s++ {
+					want, got := expectedArea.GetSample(x, y, s), area.GetSample(offsetX+x, offsetY+y, s)
+					if want.Iterations() != got.Iterations() || want.Escaped() != got.Escaped() ||
+						want.SmoothIterations() != got.SmoothIterations() || want.Interior() != got.Interior() ||
+						want.Period() != got.Period() || !sameFloat(want.Distance(), got.Distance()) {
+						t.Fatalf("Pixel %d,%d sample %d expected %+v, got %+v", offsetX+x, offsetY+y, s, want, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func sameFloat(a, b float64) bool {
+	return a == b || math.IsNaN(a) && math.IsNaN(b)
+}
+
+func TestRawRoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		// The areas at the edges are cut, so the pixels of the areas have to be placed in the image.
+		pic, err := NewPictureWithOptions(70, 45,
+			WithView(View{Center: complex(-0.75, 0.1), Zoom: 4, Rotation: 0.3}),
+			WithTileSize(20, 20),
+			WithMaxIterations(200),
+			WithDistance(),
+			WithInterior(),
+			WithJulia(complex(-0.4, 0.6)),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pic.Init()
+		loaded := rawRoundTrip(t, pic, compress)
+		assertSamePixels(t, pic, loaded)
+
+		if loaded.MaxIterations != 200 || !loaded.Distance || !loaded.Interior || !loaded.Julia || loaded.C != complex(-0.4, 0.6) ||
+			loaded.Rotation != 0.3 || loaded.Formula != nil {
+			t.Errorf("Parameters not restored, got %+v", loaded)
+		}
+		area, loadedArea := pic.GetArea(0), loaded.GetArea(0)
+		if math.Abs(area.PixelSize()-loadedArea.PixelSize()) > 1e-15 {
+			t.Errorf("Expected pixel size %g, got %g", area.PixelSize(), loadedArea.PixelSize())
+		}
+		if loaded.TopLeft != pic.TopLeft {
+			t.Errorf("Expected top left %v, got %v", pic.TopLeft, loaded.TopLeft)
+		}
+	}
+}
+
+func TestRawSamples(t *testing.T) {
+	for _, option := range []Option{WithSamples(4, GridSampling), WithAdaptiveSampling(2, 1)} {
+		pic, err := NewPictureWithOptions(40, 30, WithTileSize(16, 16), WithMaxIterations(100), WithFormula(Multibrot{Power: 3}), option)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pic.Init()
+		assertSamePixels(t, pic, rawRoundTrip(t, pic, true))
+	}
+}
+
+func TestRawPrecision(t *testing.T) {
+	center, err := ParseBigComplex("-0.743643887037158704752191506114774", "0.131825904205311970493132056385139", 160)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The float64 corners of the areas are the same number at this zoom.
+	view := View{Center: center.Complex128(), BigCenter: &center, Zoom: 1e17}
+	pic, err := NewPictureWithOptions(20, 20, WithView(view), WithMaxIterations(500), WithPrecision(160))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	loaded := rawRoundTrip(t, pic, false)
+	assertSamePixels(t, pic, loaded)
+	if loaded.BigTopLeft == nil || loaded.BigTopLeft.Real.Cmp(pic.BigTopLeft.Real) != 0 || loaded.BigTopLeft.Imag.Cmp(pic.BigTopLeft.Imag) != 0 {
+		t.Errorf("Expected the top left %v, got %v", pic.BigTopLeft, loaded.BigTopLeft)
+	}
+	if loaded.Precision != 160 {
+		t.Errorf("Expected precision 160, got %d", loaded.Precision)
+	}
+	area, loadedArea := pic.GetArea(0), loaded.GetArea(0)
+	pixelSize := area.PixelSize()
+	if got := loadedArea.PixelSize(); pixelSize == 0 || math.Abs(got-pixelSize) > pixelSize*1e-12 {
+		t.Errorf("Expected pixel size %g, got %g", pixelSize, got)
+	}
+
+	saved, resaved := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := pic.SaveRaw(saved, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.SaveRaw(resaved, false); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(saved.Bytes(), resaved.Bytes()) {
+		t.Error("Expected the same raw data after loading it")
+	}
+}
+
+func TestRawInvalid(t *testing.T) {
+	if _, err := LoadRaw(bytes.NewBufferString("not raw data")); err == nil {
+		t.Error("Expected an error for an invalid file")
+	}
+
+	pic, err := NewPictureWithOptions(10, 10, WithMaxIterations(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	buffer := &bytes.Buffer{}
+	if err := pic.SaveRaw(buffer, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRaw(bytes.NewReader(buffer.Bytes()[:buffer.Len()-1])); err == nil {
+		t.Error("Expected an error for a truncated file")
+	}
+
+	// The header claims about 2^30 pixels but the body only has 100, the pixels are not allocated before reading them.
+	huge := append([]byte{}, buffer.Bytes()...)
+	binary.LittleEndian.PutUint32(huge[5:], 1<<15)
+	binary.LittleEndian.PutUint32(huge[9:], 1<<15)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := LoadRaw(bytes.NewReader(huge)); err == nil {
+		t.Error("Expected an error for a body smaller than the size in the header")
+	}
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("Expected a small allocation for a truncated body, got %d bytes", allocated)
+	}
+
+	var iterations int64
+	pic.Formula = countingFormula{iterations: &iterations}
+	if err := pic.SaveRaw(&bytes.Buffer{}, false); err == nil {
+		t.Error("Expected an error for a formula that can't be parsed")
+	}
+}
@@ -0,0 +1,185 @@
+package mandelbrot
+
+import (
+	"math"
+	"math/rand"
+)
+
+// SamplingPattern chooses where the samples of a pixel are placed when an Area is supersampled.
+type SamplingPattern int
+
+const (
+	// GridSampling places the samples in a regular grid that starts at the top left corner of the pixel.
+	GridSampling SamplingPattern = iota
+	// JitteredSampling divides the pixel like GridSampling and places each sample at a random position of its cell.
+	JitteredSampling
+	// RandomSampling places the samples at random positions of the pixel.
+	RandomSampling
+)
+
+// samplingSeed makes the random patterns the same on every calculation.
+const samplingSeed = 1
+
+// String returns the name of the pattern.
+func (s SamplingPattern) String() string {
+	switch s {
+	case GridSampling:
+		return "grid"
+	case JitteredSampling:
+		return "jittered"
+	case RandomSampling:
+		return "random"
+	}
+	return "unknown"
+}
+
+// samplingPatterns are all the known patterns.
+var samplingPatterns = []SamplingPattern{GridSampling, JitteredSampling, RandomSampling}
+
+// valid reports whether s is one of the known patterns.
+func (s SamplingPattern) valid() bool {
+	for _, pattern := range samplingPatterns {
+		if s == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// SamplingPatternByName returns the pattern with the given name, see SamplingPattern.String.
+func SamplingPatternByName(name string) (SamplingPattern, bool) {
+	for _, pattern := range samplingPatterns {
+		if pattern.String() == name {
+			return pattern, true
+		}
+	}
+	return 0, false
+}
+
+// sampler gives the position of the samples inside a pixel, in pixel units.
+type sampler struct {
+	pattern SamplingPattern
+	samples int
+	columns int
+	rows    int
+	random  *rand.Rand
+}
+
+func newSampler(pattern SamplingPattern, samples int) *sampler {
+	columns := int(math.Ceil(math.Sqrt(float64(samples))))
+	return &sampler{
+		pattern: pattern,
+		samples: samples,
+		columns: columns,
+		rows:    (samples + columns - 1) / columns,
+		random:  rand.New(rand.NewSource(samplingSeed)),
+	}
+}
+
+// position returns the position of the sample s inside the pixel, both coordinates are in the range [0, 1).
+func (s *sampler) position(sample int) (x, y float64) {
+	if s.pattern == RandomSampling {
+		return s.random.Float64(), s.random.Float64()
+	}
+	x, y = float64(sample%s.columns), float64(sample/s.columns)
+	if s.pattern == JitteredSampling {
+		x, y = x+s.random.Float64(), y+s.random.Float64()
+	}
+	return x / float64(s.columns), y / float64(s.rows)
+}
+
+// initSamples places the samples of each pixel with the Sampling pattern, the first one replaces the point in Points.
+func (a *Area) initSamples() {
+	a.samples = make([][]Point, len(a.Points))
+	sampler := newSampler(a.Sampling, a.Samples)
+	for i := range a.Points {
+		x, y := a.ForIndex(i)
+		a.samples[i] = make([]Point, a.Samples-1)
+		for s := 0; s < a.Samples; s++ {
+			dx, dy := sampler.position(s)
+			point := Point{Point: a.TopLeft + a.getOffsetAt(float64(x)+dx, float64(y)+dy)}
+			if s == 0 {
+				a.Points[i] = point
+			} else {
+				a.samples[i][s-1] = point
+			}
+		}
+	}
+}
+
+// refine supersamples the pixels that differ from their neighbors, see Area.AdaptiveDepth.
+// The samples of previous calculations are kept, so it can be called again after Deepen.
+func (a *Area) refine(params Params) {
+	for i := range a.Points {
+		x, y := a.ForIndex(i)
+		level := gridLevel(len(a.samples[i]) + 1)
+		if level == 0 && !a.differsFromNeighbors(x, y) {
+			continue
+		}
+		for level < a.AdaptiveDepth && (level == 0 || a.samplesDiffer(i)) {
+			level++
+			a.samples[i] = a.gridSamples(x, y, level, a.samples[i], params)
+		}
+	}
+}
+
+// gridLevel returns the level of a grid of 2^level x 2^level samples.
+func gridLevel(samples int) int {
+	level := 0
+	for size := 1; size < samples; size *= 4 {
+		level++
+	}
+	return level
+}
+
+// differsFromNeighbors reports whether the point at x,y differs from any of its neighbors by more than AdaptiveThreshold.
+func (a *Area) differsFromNeighbors(x, y int) bool {
+	iterations := a.Points[a.IndexFor(x, y)].Iterations()
+	for _, neighbor := range [][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+		nx, ny := neighbor[0], neighbor[1]
+		if nx < 0 || ny < 0 || nx >= a.HorizontalResolution || ny >= a.VerticalResolution {
+			continue
+		}
+		if math.Abs(float64(iterations-a.Points[a.IndexFor(nx, ny)].Iterations())) > a.AdaptiveThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// samplesDiffer reports whether the samples of the pixel i differ by more than AdaptiveThreshold.
+func (a *Area) samplesDiffer(i int) bool {
+	min, max := a.Points[i].Iterations(), a.Points[i].Iterations()
+	for j := range a.samples[i] {
+		iterations := a.samples[i][j].Iterations()
+		if iterations < min {
+			min = iterations
+		}
+		if iterations > max {
+			max = iterations
+		}
+	}
+	return float64(max-min) > a.AdaptiveThreshold
+}
+
+// gridSamples returns the samples of the pixel x,y for a grid of the given level, the samples of the previous level
+// are reused and the new ones are calculated.
+func (a *Area) gridSamples(x, y, level int, previous []Point, params Params) []Point {
+	size := 1 << uint(level)
+	samples := make([]Point, 0, size*size-1)
+	for row := 0; row < size; row++ {
+		for column := 0; column < size; column++ {
+			if row == 0 && column == 0 {
+				continue
+			}
+			if level > 1 && row%2 == 0 && column%2 == 0 {
+				samples = append(samples, previous[(row/2)*(size/2)+column/2-1])
+				continue
+			}
+			sample := Point{Point: a.TopLeft + a.getOffsetAt(float64(x)+float64(column)/float64(size), float64(y)+float64(row)/float64(size))}
+			sample.CalculateParams(params)
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
@@ -0,0 +1,154 @@
+package mandelbrot_test
+
+import (
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+func TestSamplingPatternByName(t *testing.T) {
+	for _, pattern := range []SamplingPattern{GridSampling, JitteredSampling, RandomSampling} {
+		got, ok := SamplingPatternByName(pattern.String())
+		if !ok || got != pattern {
+			t.Errorf("Pattern %s not found by name, got %s", pattern, got)
+		}
+	}
+	if _, ok := SamplingPatternByName("unknown"); ok {
+		t.Errorf("Unknown pattern must not be found")
+	}
+}
+
+func newSampledArea(pattern SamplingPattern, samples int) *Area {
+	area := &Area{
+		HorizontalResolution: 4,
+		VerticalResolution:   4,
+		MaxIterations:        100,
+		TopLeft:              complex(-2, 2),
+		BottomRight:          complex(2, -2),
+		Samples:              samples,
+		Sampling:             pattern,
+	}
+	area.Init()
+	return area
+}
+
+func TestGridSampling(t *testing.T) {
+	area := newSampledArea(GridSampling, 4)
+	if area.SampleCount(2, 2) != 4 {
+		t.Fatalf("Expected 4 samples, got %d", area.SampleCount(2, 2))
+	}
+	expected := []complex128{complex(0, 0), complex(0.5, 0), complex(0, -0.5), complex(0.5, -0.5)}
+	for s, offset := range expected {
+		sample := area.GetSample(2, 2, s)
+		if sample.Point != offset {
+			t.Errorf("Sample %d expected at %f, got %f", s, offset, sample.Point)
+		}
+	}
+}
+
+func TestRandomSamplingInsidePixel(t *testing.T) {
+	for _, pattern := range []SamplingPattern{JitteredSampling, RandomSampling} {
+		area := newSampledArea(pattern, 9)
+		again := newSampledArea(pattern, 9)
+		for x := 0; x < area.HorizontalResolution; x++ {
+			for y := 0; y < area.VerticalResolution; y++ {
+				left, top := -2+float64(x), 2-float64(y)
+				for s := 0; s < area.SampleCount(x, y); s++ {
+					sample := area.GetSample(x, y, s)
+					if real(sample.Point) < left || real(sample.Point) >= left+1 || imag(sample.Point) > top || imag(sample.Point) <= top-1 {
+						t.Errorf("%s sample %d of pixel %d,%d is outside the pixel, got %f", pattern, s, x, y, sample.Point)
+					}
+					if again.GetSample(x, y, s) != sample {
+						t.Errorf("%s sample %d of pixel %d,%d is not the same on every Init", pattern, s, x, y)
+					}
+				}
+			}
+		}
+	}
+}
+
+func newAdaptiveArea(depth int, threshold float64) *Area {
+	area := NewAreaCentered(40, 200, -0.75, 0.1, 0.05)
+	area.AdaptiveDepth = depth
+	area.AdaptiveThreshold = threshold
+	area.Init()
+	area.Calculate()
+	return area
+}
+
+func TestAdaptiveSampling(t *testing.T) {
+	area := newAdaptiveArea(2, 1)
+	plain := NewAreaCentered(40, 200, -0.75, 0.1, 0.05)
+	plain.Init()
+	plain.Calculate()
+
+	total := 0
+	for x := 0; x < area.HorizontalResolution; x++ {
+		for y := 0; y < area.VerticalResolution; y++ {
+			samples := area.SampleCount(x, y)
+			total += samples
+			if samples != 1 && samples != 4 && samples != 16 {
+				t.Errorf("Pixel %d,%d must have a grid of samples, got %d", x, y, samples)
+			}
+			differs := false
+			iterations := plain.Points[plain.IndexFor(x, y)].Iterations()
+			for _, neighbor := range [][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				if neighbor[0] < 0 || neighbor[1] < 0 || neighbor[0] >= plain.HorizontalResolution || neighbor[1] >= plain.VerticalResolution {
+					continue
+				}
+				other := plain.Points[plain.IndexFor(neighbor[0], neighbor[1])].Iterations()
+				if other-iterations > 1 || iterations-other > 1 {
+					differs = true
+				}
+			}
+			if differs != (samples > 1) {
+				t.Errorf("Pixel %d,%d differs from its neighbors %t but has %d samples", x, y, differs, samples)
+			}
+		}
+	}
+	if uniform := 16 * area.HorizontalResolution * area.VerticalResolution; total >= uniform {
+		t.Errorf("Adaptive sampling must calculate less samples than uniform sampling, got %d and %d", total, uniform)
+	}
+}
+
+func TestAdaptiveSamplingMatchesGrid(t *testing.T) {
+	area := newAdaptiveArea(1, 0)
+	grid := NewAreaCentered(40, 200, -0.75, 0.1, 0.05)
+	grid.Samples = 4
+	grid.Init()
+	grid.Calculate()
+
+	for x := 0; x < area.HorizontalResolution; x++ {
+		for y := 0; y < area.VerticalResolution; y++ {
+			if area.SampleCount(x, y) == 1 {
+				continue
+			}
+			for s := 0; s < 4; s++ {
+				if area.GetSample(x, y, s) != grid.GetSample(x, y, s) {
+					t.Errorf("Sample %d of pixel %d,%d differs from the grid sample", s, x, y)
+				}
+			}
+		}
+	}
+}
+
+var sampledArea *Area
+
+func BenchmarkUniformSampling(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sampledArea = NewAreaCentered(40, 1000, -0.75, 0.1, 0.05)
+		sampledArea.Samples = 16
+		sampledArea.Init()
+		sampledArea.Calculate()
+	}
+}
+
+func BenchmarkAdaptiveSampling(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sampledArea = NewAreaCentered(40, 1000, -0.75, 0.1, 0.05)
+		sampledArea.AdaptiveDepth = 2
+		sampledArea.AdaptiveThreshold = 1
+		sampledArea.Init()
+		sampledArea.Calculate()
+	}
+}
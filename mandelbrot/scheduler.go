@@ -0,0 +1,219 @@
+package mandelbrot
+
+import (
+	"context"
+	"fmt"
+	"runtime/trace"
+	"sort"
+	"sync"
+)
+
+// Scheduler chooses how the areas of a Picture are shared between the workers.
+type Scheduler int
+
+const (
+	// QueueScheduler hands out the areas one by one from a single queue shared by all the workers.
+	QueueScheduler Scheduler = iota
+	// StealingScheduler estimates the cost of each area with a low resolution prepass and splits the work in
+	// balanced queues, one per worker. A worker that runs out of areas steals them from the busiest worker, so the
+	// areas inside the set, that are much more expensive, don't leave the other workers idle at the end.
+	StealingScheduler
+)
+
+// costSamples is the number of points in each direction calculated to estimate the cost of an area.
+const costSamples = 4
+
+// String returns the name of the scheduler.
+func (s Scheduler) String() string {
+	switch s {
+	case QueueScheduler:
+		return "queue"
+	case StealingScheduler:
+		return "stealing"
+	}
+	return "unknown"
+}
+
+// schedulers are all the known schedulers.
+var schedulers = []Scheduler{QueueScheduler, StealingScheduler}
+
+// valid reports whether s is one of the known schedulers.
+func (s Scheduler) valid() bool {
+	for _, scheduler := range schedulers {
+		if s == scheduler {
+			return true
+		}
+	}
+	return false
+}
+
+// SchedulerByName returns the scheduler with the given name, see Scheduler.String.
+func SchedulerByName(name string) (Scheduler, bool) {
+	for _, scheduler := range schedulers {
+		if scheduler.String() == name {
+			return scheduler, true
+		}
+	}
+	return 0, false
+}
+
+// estimateCost returns the iterations that the calculation of the area is expected to take, it is extrapolated from
+// a grid of costSamples x costSamples points. The points of the grid are calculated in place when the area is
+// calculated with float64, so the prepass is not wasted.
+func (a *Area) estimateCost() float64 {
+	params := a.params()
+	inPlace := a.bigPoints == nil && a.Reference == nil
+	iterations := 0
+	for row := 0; row < costSamples; row++ {
+		for column := 0; column < costSamples; column++ {
+			x := (2*column + 1) * a.HorizontalResolution / (2 * costSamples)
+			y := (2*row + 1) * a.VerticalResolution / (2 * costSamples)
+			point := Point{Point: a.TopLeft + a.getOffset(x, y)}
+			if inPlace {
+				point = a.Points[a.IndexFor(x, y)]
+			}
+			point.CalculateParams(params)
+			if inPlace {
+				a.Points[a.IndexFor(x, y)] = point
+			}
+			// Every point has a cost even if it escapes immediately.
+			iterations += point.Iterations() + 1
+		}
+	}
+	return float64(iterations) * float64(len(a.Points)) / (costSamples * costSamples)
+}
+
+// deque holds the areas assigned to a worker, the worker takes them from the front and the others steal them from
+// the back.
+type deque struct {
+	mutex sync.Mutex
+	work  []int
+	cost  float64
+	costs []float64
+}
+
+func (d *deque) popFront() (int, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(d.work) == 0 {
+		return 0, false
+	}
+	i := d.work[0]
+	d.work = d.work[1:]
+	d.cost -= d.costs[i]
+	return i, true
+}
+
+func (d *deque) popBack() (int, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(d.work) == 0 {
+		return 0, false
+	}
+	i := d.work[len(d.work)-1]
+	d.work = d.work[:len(d.work)-1]
+	d.cost -= d.costs[i]
+	return i, true
+}
+
+func (d *deque) remaining() float64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(d.work) == 0 {
+		return 0
+	}
+	return d.cost
+}
+
+// balance splits the work in one deque per worker. The most expensive areas are given first to the worker with
+// less work, and then each deque keeps the order of work.
+func balance(work []int, costs []float64, workerCount int) []*deque {
+	deques := make([]*deque, workerCount)
+	for w := range deques {
+		deques[w] = &deque{costs: costs}
+	}
+
+	byCost := append([]int(nil), work...)
+	sort.SliceStable(byCost, func(i, j int) bool {
+		return costs[byCost[i]] > costs[byCost[j]]
+	})
+	owner := make(map[int]*deque, len(work))
+	for _, i := range byCost {
+		lightest := deques[0]
+		for _, d := range deques[1:] {
+			if d.cost < lightest.cost {
+				lightest = d
+			}
+		}
+		lightest.cost += costs[i]
+		owner[i] = lightest
+	}
+	for _, i := range work {
+		owner[i].work = append(owner[i].work, i)
+	}
+	return deques
+}
+
+// steal takes an area from the back of the deque with more remaining work.
+func steal(deques []*deque) (int, bool) {
+	for {
+		var busiest *deque
+		for _, d := range deques {
+			if remaining := d.remaining(); remaining > 0 && (busiest == nil || remaining > busiest.remaining()) {
+				busiest = d
+			}
+		}
+		if busiest == nil {
+			return 0, false
+		}
+		// Other worker may have emptied it in the meantime, then another victim is chosen.
+		if i, ok := busiest.popBack(); ok {
+			return i, true
+		}
+	}
+}
+
+// calculateStealing calculates the work with the StealingScheduler.
+func (p *Picture) calculateStealing(ctx context.Context, work []int, workerCount int, doneIndex chan<- int) {
+	// The prepass is also shared by the workers, each one estimates the cost of every workerCount areas.
+	costs := make([]float64, len(p.areas))
+	wg := &sync.WaitGroup{}
+	wg.Add(workerCount)
+	for worker := 0; worker < workerCount; worker++ {
+		go func(worker int) {
+			defer wg.Done()
+			for j := worker; j < len(work) && ctx.Err() == nil; j += workerCount {
+				costs[work[j]] = p.areas[work[j]].estimateCost()
+			}
+		}(worker)
+	}
+	wg.Wait()
+	deques := balance(work, costs, workerCount)
+
+	wg.Add(workerCount)
+	for _, own := range deques {
+		go func(own *deque) {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i, ok := own.popFront()
+				if !ok {
+					i, ok = steal(deques)
+				}
+				if !ok {
+					return
+				}
+				areaRegion := trace.StartRegion(ctx, fmt.Sprintf("Area %d", i))
+				p.areas[i].Calculate()
+				areaRegion.End()
+				if !p.finish(ctx, i) {
+					return
+				}
+				select {
+				case <-ctx.Done():
+				case doneIndex <- i:
+				}
+			}
+		}(own)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,70 @@
+package mandelbrot_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+func TestStealingScheduler(t *testing.T) {
+	newPicture := func(scheduler Scheduler) *Picture {
+		pic, err := NewPictureWithOptions(120, 90, WithTileSize(10, 10), WithMaxIterations(500), WithScheduler(scheduler), WithoutSymmetry())
+		if err != nil {
+			t.Fatal(err)
+		}
+		pic.Init()
+		return pic
+	}
+	expected, got := newPicture(QueueScheduler), newPicture(StealingScheduler)
+	for range expected.CalculateAsync(context.Background(), 3) {
+	}
+	calculated := make(map[int]int)
+	for i := range got.CalculateAsync(context.Background(), 3) {
+		calculated[i]++
+	}
+
+	for i := 0; i < got.HorizontalImageChunks*got.VerticalImageChunks; i++ {
+		if calculated[i] != 1 {
+			t.Errorf("Area %d calculated %d times", i, calculated[i])
+		}
+		expectedArea, gotArea := expected.GetArea(i), got.GetArea(i)
+		for j := range expectedArea.Points {
+			if expectedArea.Points[j] != gotArea.Points[j] {
+				t.Fatalf("Area %d point %d differs", i, j)
+			}
+		}
+	}
+}
+
+func TestStealingSchedulerCancel(t *testing.T) {
+	pic, err := NewPictureWithOptions(200, 200, WithTileSize(10, 10), WithMaxIterations(1000), WithScheduler(StealingScheduler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := 0
+	for range pic.CalculateAsync(ctx, 4) {
+		done++
+		if done == 5 {
+			cancel()
+		}
+	}
+	if done >= pic.HorizontalImageChunks*pic.VerticalImageChunks {
+		t.Errorf("Expected the calculation to stop, got %d areas", done)
+	}
+}
+
+func TestSchedulerByName(t *testing.T) {
+	for _, scheduler := range []Scheduler{QueueScheduler, StealingScheduler} {
+		got, ok := SchedulerByName(scheduler.String())
+		if !ok || got != scheduler {
+			t.Errorf("Expected scheduler %s, got %s", scheduler, got)
+		}
+	}
+	if _, ok := SchedulerByName("fifo"); ok {
+		t.Error("Expected unknown scheduler")
+	}
+}
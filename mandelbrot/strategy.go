@@ -0,0 +1,56 @@
+package mandelbrot
+
+// Strategy chooses how the points of an Area are visited by Calculate.
+type Strategy int
+
+const (
+	// BruteForceStrategy calculates every point of the area.
+	BruteForceStrategy Strategy = iota
+	// SubdivisionStrategy calculates the border of the area and fills it without iterating when the whole border is
+	// inside the set, otherwise the area is split in four and the process is repeated for each part.
+	// It is the Mariani-Silver algorithm, it is much faster for areas inside the set but it can miss details that
+	// do not reach the border of a rectangle. The escaped points are always calculated, so their smooth iterations
+	// and distance are exact, and the filled points take the period of the border when Interior is set.
+	SubdivisionStrategy
+	// BoundaryTracingStrategy follows the edges between regions with different iterations and fills the regions
+	// inside the set without iterating their inner points. It gives the same result as BruteForceStrategy unless a
+	// detail is completely surrounded by the set without touching its edge, that does not happen with the Mandelbrot
+	// and Julia sets but it does with islands of formulas like BurningShip. Nothing is filled when Interior is set.
+	BoundaryTracingStrategy
+)
+
+// String returns the name of the strategy.
+func (s Strategy) String() string {
+	switch s {
+	case BruteForceStrategy:
+		return "bruteforce"
+	case SubdivisionStrategy:
+		return "subdivision"
+	case BoundaryTracingStrategy:
+		return "boundary"
+	}
+	return "unknown"
+}
+
+// strategies are all the known strategies.
+var strategies = []Strategy{BruteForceStrategy, SubdivisionStrategy, BoundaryTracingStrategy}
+
+// valid reports whether s is one of the known strategies.
+func (s Strategy) valid() bool {
+	for _, strategy := range strategies {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// StrategyByName returns the strategy with the given name, see Strategy.String.
+func StrategyByName(name string) (Strategy, bool) {
+	for _, strategy := range strategies {
+		if strategy.String() == name {
+			return strategy, true
+		}
+	}
+	return 0, false
+}
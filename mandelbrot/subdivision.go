@@ -0,0 +1,101 @@
+package mandelbrot
+
+// minimumSubdivision is the size of the rectangles that are calculated point by point instead of being split again.
+const minimumSubdivision = 4
+
+// rectangle is a region of the points of an area, both corners are included.
+type rectangle struct {
+	left, top, right, bottom int
+}
+
+// calculateSubdivision calculates the points of the area with the SubdivisionStrategy.
+func (a *Area) calculateSubdivision(params Params) {
+	calculated := make([]bool, len(a.Points))
+	calculate := func(x, y int) {
+		i := a.IndexFor(x, y)
+		if !calculated[i] {
+			a.Points[i].CalculateParams(params)
+			calculated[i] = true
+		}
+	}
+
+	whole := rectangle{0, 0, a.HorizontalResolution - 1, a.VerticalResolution - 1}
+	for x := whole.left; x <= whole.right; x++ {
+		calculate(x, whole.top)
+		calculate(x, whole.bottom)
+	}
+	for y := whole.top; y <= whole.bottom; y++ {
+		calculate(whole.left, y)
+		calculate(whole.right, y)
+	}
+
+	pending := []rectangle{whole}
+	for len(pending) > 0 {
+		r := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if r.right-r.left < 2 || r.bottom-r.top < 2 {
+			continue
+		}
+		if r.right-r.left <= minimumSubdivision || r.bottom-r.top <= minimumSubdivision {
+			for x := r.left + 1; x < r.right; x++ {
+				for y := r.top + 1; y < r.bottom; y++ {
+					calculate(x, y)
+				}
+			}
+			continue
+		}
+		if a.uniformBorder(r) {
+			a.fill(r)
+			continue
+		}
+		middleX, middleY := (r.left+r.right)/2, (r.top+r.bottom)/2
+		for x := r.left + 1; x < r.right; x++ {
+			calculate(x, middleY)
+		}
+		for y := r.top + 1; y < r.bottom; y++ {
+			calculate(middleX, y)
+		}
+		pending = append(pending,
+			rectangle{r.left, r.top, middleX, middleY},
+			rectangle{middleX, r.top, r.right, middleY},
+			rectangle{r.left, middleY, middleX, r.bottom},
+			rectangle{middleX, middleY, r.right, r.bottom},
+		)
+	}
+}
+
+// uniformBorder reports whether all the points in the border of the rectangle are inside the set with the same
+// period. Escaped points are never uniform, because their smooth iterations and distance are different even if
+// they have the same iterations.
+func (a *Area) uniformBorder(r rectangle) bool {
+	corner := &a.Points[a.IndexFor(r.left, r.top)]
+	if corner.escaped {
+		return false
+	}
+	same := func(x, y int) bool {
+		point := &a.Points[a.IndexFor(x, y)]
+		return point.iterations == corner.iterations && !point.escaped && point.interior == corner.interior && point.period == corner.period
+	}
+	for x := r.left; x <= r.right; x++ {
+		if !same(x, r.top) || !same(x, r.bottom) {
+			return false
+		}
+	}
+	for y := r.top; y <= r.bottom; y++ {
+		if !same(r.left, y) || !same(r.right, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// fill copies the result of the top left corner of the rectangle to the points inside its border.
+func (a *Area) fill(r rectangle) {
+	corner := a.Points[a.IndexFor(r.left, r.top)]
+	for x := r.left + 1; x < r.right; x++ {
+		for y := r.top + 1; y < r.bottom; y++ {
+			i := a.IndexFor(x, y)
+			a.Points[i] = corner.filledAt(a.Points[i].Point)
+		}
+	}
+}
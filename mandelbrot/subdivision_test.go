@@ -0,0 +1,98 @@
+package mandelbrot_test
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+func newStrategyArea(strategy Strategy, maxIterations int, x, y, size float64) *Area {
+	area := NewAreaCentered(120, maxIterations, x, y, size)
+	area.Strategy = strategy
+	area.Init()
+	area.Calculate()
+	return area
+}
+
+// newDetailedStrategyArea works like newStrategyArea with the distance and the interior detection.
+func newDetailedStrategyArea(strategy Strategy, maxIterations int, x, y, size float64) *Area {
+	area := NewAreaCentered(120, maxIterations, x, y, size)
+	area.Strategy = strategy
+	area.Distance = true
+	area.Interior = true
+	area.Init()
+	area.Calculate()
+	return area
+}
+
+// sameResult reports whether both points have the same result for every coloring.
+func sameResult(expected, got Point) bool {
+	sameDistance := expected.Distance() == got.Distance() || math.IsNaN(expected.Distance()) && math.IsNaN(got.Distance())
+	return expected.Iterations() == got.Iterations() && expected.Escaped() == got.Escaped() &&
+		expected.SmoothIterations() == got.SmoothIterations() && sameDistance &&
+		expected.Interior() == got.Interior() && expected.Period() == got.Period()
+}
+
+// differences returns the number of points with a different result.
+func differences(expected, got *Area) int {
+	count := 0
+	for i := range expected.Points {
+		if !sameResult(expected.Points[i], got.Points[i]) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSubdivisionMatchesBruteForce(t *testing.T) {
+	tests := []struct {
+		x, y, size float64
+	}{
+		{-0.1, 0, 0.2},
+		{-1, 0, 0.1},
+		{-0.75, 0, 1.5},
+		{-0.7435669, 0.1314023, 0.001},
+	}
+
+	for i, test := range tests {
+		expected := newDetailedStrategyArea(BruteForceStrategy, 500, test.x, test.y, test.size)
+		got := newDetailedStrategyArea(SubdivisionStrategy, 500, test.x, test.y, test.size)
+		if count := differences(expected, got); count > 0 {
+			t.Errorf("Test %d failed, %d of %d points differ", i, count, len(expected.Points))
+		}
+	}
+}
+
+func TestSubdivisionDeepen(t *testing.T) {
+	oneShot := newStrategyArea(SubdivisionStrategy, 2000, -0.75, 0, 1.5)
+	incremental := newStrategyArea(SubdivisionStrategy, 50, -0.75, 0, 1.5)
+	incremental.Deepen(500)
+	incremental.Deepen(2000)
+	if count := differences(oneShot, incremental); count > 0 {
+		t.Errorf("%d points differ after Deepen", count)
+	}
+}
+
+func TestStrategyByName(t *testing.T) {
+	for _, strategy := range []Strategy{BruteForceStrategy, SubdivisionStrategy, BoundaryTracingStrategy} {
+		got, ok := StrategyByName(strategy.String())
+		if !ok || got != strategy {
+			t.Errorf("Strategy %s not found by name, got %s", strategy, got)
+		}
+	}
+}
+
+func benchmarkStrategy(b *testing.B, strategy Strategy) {
+	for i := 0; i < b.N; i++ {
+		sampledArea = newStrategyArea(strategy, 2000, -0.75, 0, 1.5)
+	}
+}
+
+func BenchmarkBruteForceStrategy(b *testing.B) {
+	benchmarkStrategy(b, BruteForceStrategy)
+}
+
+func BenchmarkSubdivisionStrategy(b *testing.B) {
+	benchmarkStrategy(b, SubdivisionStrategy)
+}
@@ -0,0 +1,58 @@
+package mandelbrot
+
+import "math"
+
+// mirrorTolerance is the fraction of a pixel that two rows can be apart to be considered the mirror of each other.
+const mirrorTolerance = 1e-6
+
+// isConjugateSymmetric reports whether the formula commutes with conjugation, the Mandelbrot formula does.
+func isConjugateSymmetric(formula Formula) bool {
+	if formula == nil {
+		return true
+	}
+	symmetric, ok := formula.(ConjugateSymmetric)
+	return ok && symmetric.ConjugateSymmetric()
+}
+
+// mirrors returns which areas are below the real axis and mirror the rows of the areas above it, so they can be
+// copied instead of calculated. The row y of the picture is the mirror of the row offset - y.
+// It returns nil if the picture is not symmetric.
+func (p *Picture) mirrors() (mirrored []bool, offset int) {
+	if p.DisableSymmetry || p.Rotation != 0 || p.Precision > 0 || p.Perturbation || p.Samples > 1 || p.AdaptiveDepth > 0 {
+		return nil, 0
+	}
+	if !isConjugateSymmetric(p.Formula) || (p.Julia && imag(p.C) != 0) {
+		return nil, 0
+	}
+	pixelSize := p.ChunkSize / float64(p.chunkImageWidth())
+	rows := 2 * imag(p.TopLeft) / pixelSize
+	if math.Abs(rows-math.Round(rows)) > mirrorTolerance {
+		return nil, 0
+	}
+	offset = int(math.Round(rows))
+
+	height := p.chunkImageHeight()
+	mirrored = make([]bool, len(p.areas))
+	for i := range p.areas {
+		_, y := p.ForIndex(i)
+		first := y * height
+		last := first + p.areas[i].VerticalResolution - 1
+		mirrored[i] = 2*first > offset && offset-last >= 0
+	}
+	return mirrored, offset
+}
+
+// mirror copies the conjugate of the rows mirrored by the area i, see mirrors. The points take the coordinates of the
+// conjugates, that are at most a rounding error away from their own.
+func (p *Picture) mirror(i int, offset int) {
+	x, y := p.ForIndex(i)
+	height := p.chunkImageHeight()
+	area := &p.areas[i]
+	for row := 0; row < area.VerticalResolution; row++ {
+		source := offset - (y*height + row)
+		sourceArea := &p.areas[p.IndexFor(x, source/height)]
+		for column := 0; column < area.HorizontalResolution; column++ {
+			area.Points[area.IndexFor(column, row)] = sourceArea.Points[sourceArea.IndexFor(column, source%height)].conjugate()
+		}
+	}
+}
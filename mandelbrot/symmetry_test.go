@@ -0,0 +1,99 @@
+package mandelbrot_test
+
+import (
+	"context"
+	"math/cmplx"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+// countingFormula counts the iterations of the Mandelbrot formula.
+type countingFormula struct {
+	symmetric  bool
+	iterations *int64
+}
+
+func (f countingFormula) Iterate(z, c complex128) complex128 {
+	atomic.AddInt64(f.iterations, 1)
+	return z*z + c
+}
+
+func (f countingFormula) Degree() float64 {
+	return 2
+}
+
+func (f countingFormula) String() string {
+	return "counting"
+}
+
+func (f countingFormula) ConjugateSymmetric() bool {
+	return f.symmetric
+}
+
+func calculateView(view View, formula Formula, disableSymmetry bool) *Picture {
+	pic, err := view.Picture(60, 3, 200)
+	if err != nil {
+		panic(err)
+	}
+	pic.Formula = formula
+	pic.DisableSymmetry = disableSymmetry
+	pic.Init()
+	for range pic.CalculateAsync(context.Background(), 2) {
+	}
+	return pic
+}
+
+func TestPictureSymmetry(t *testing.T) {
+	views := []View{
+		{Center: complex(-0.75, 0)},
+		{Center: complex(-0.75, 0.1), Zoom: 2},
+		{Center: complex(-1.25, -0.25), Zoom: 4, Aspect: 2},
+	}
+
+	for i, view := range views {
+		for _, formula := range []Formula{nil, Tricorn{}, Celtic{}} {
+			expected := calculateView(view, formula, true)
+			got := calculateView(view, formula, false)
+			for j := 0; j < expected.HorizontalImageChunks*expected.VerticalImageChunks; j++ {
+				expectedArea, gotArea := expected.GetArea(j), got.GetArea(j)
+				for k := range expectedArea.Points {
+					e, g := expectedArea.Points[k], gotArea.Points[k]
+					if cmplx.Abs(e.Point-g.Point) > 1e-12 {
+						t.Fatalf("View %d with formula %v failed, area %d point %f is at %f", i, formula, j, e.Point, g.Point)
+					}
+					// The mirrored points are at the exact conjugate of the calculated ones.
+					fresh := Point{Point: g.Point}
+					fresh.CalculateParams(Params{MaxIterations: 200, Formula: formula})
+					if fresh.Iterations() != g.Iterations() || fresh.SmoothIterations() != g.SmoothIterations() || fresh.Z() != g.Z() {
+						t.Errorf("View %d with formula %v failed, area %d point %f expected %d iterations got %d", i, formula, j, g.Point, fresh.Iterations(), g.Iterations())
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPictureSymmetrySkipsWork(t *testing.T) {
+	tests := []struct {
+		view      View
+		symmetric bool
+		saved     bool
+	}{
+		{View{Center: complex(-0.75, 0)}, true, true},
+		{View{Center: complex(-0.75, 0)}, false, false},
+		{View{Center: complex(-0.75, 1)}, true, false},
+		{View{Center: complex(-0.75, 0.01)}, true, false},
+		{View{Center: complex(-0.75, 0), Rotation: 0.5}, true, false},
+	}
+
+	for i, test := range tests {
+		var disabled, enabled int64
+		calculateView(test.view, countingFormula{test.symmetric, &disabled}, true)
+		calculateView(test.view, countingFormula{test.symmetric, &enabled}, false)
+		if saved := enabled < disabled; saved != test.saved {
+			t.Errorf("Test %d failed, expected saved work %t but got %d iterations with symmetry and %d without", i, test.saved, enabled, disabled)
+		}
+	}
+}
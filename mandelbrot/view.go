@@ -0,0 +1,94 @@
+package mandelbrot
+
+import "math"
+
+// DefaultViewWidth is the width of the complex area shown by a View with zoom 1.
+const DefaultViewWidth = 3.0
+
+// View describes the visible region of the complex plane by its center and magnification, it is an alternative to
+// specifying the TopLeft corner and the size of a Picture or an Area.
+type View struct {
+	Center complex128
+	// BigCenter is the high precision version of Center for deep zooms, Center is used if it is nil.
+	BigCenter *BigComplex
+	// Zoom is the magnification, the view is DefaultViewWidth / Zoom wide. A zoom of 1 is used when it is zero.
+	Zoom float64
+	// Aspect is the ratio between the width and the height of the view, 1 is used when it is zero.
+	Aspect float64
+	// Rotation is the angle in radians that the view is turned counterclockwise around its center.
+	Rotation float64
+}
+
+// Width returns the width of the view in the complex plane.
+func (v View) Width() float64 {
+	zoom := v.Zoom
+	if zoom == 0 {
+		zoom = 1
+	}
+	return DefaultViewWidth / zoom
+}
+
+// Height returns the height of the view in the complex plane.
+func (v View) Height() float64 {
+	aspect := v.Aspect
+	if aspect == 0 {
+		aspect = 1
+	}
+	return v.Width() / aspect
+}
+
+// TopLeft returns the top left corner of the view before the rotation.
+func (v View) TopLeft() complex128 {
+	return v.Center + v.topLeftOffset()
+}
+
+// BigTopLeft returns the top left corner of the view before the rotation with the precision of BigCenter.
+// It is nil if BigCenter is not set.
+func (v View) BigTopLeft() *BigComplex {
+	if v.BigCenter == nil {
+		return nil
+	}
+	topLeft := v.BigCenter.Add(v.topLeftOffset())
+	return &topLeft
+}
+
+// topLeftOffset returns the distance from the center to the top left corner.
+func (v View) topLeftOffset() complex128 {
+	return complex(-v.Width()/2, v.Height()/2)
+}
+
+// ImageHeight returns the height in pixels of an image of the given width that keeps the aspect of the view.
+func (v View) ImageHeight(imageWidth int) int {
+	return int(math.Round(float64(imageWidth) * v.Height() / v.Width()))
+}
+
+// Picture returns a picture of the view with the given width in pixels, the height is chosen by the aspect of the view.
+// The image is split in divisions by divisions areas, see NewRectangularPicture.
+func (v View) Picture(imageWidth int, divisions int, maxIterations int) (*Picture, error) {
+	pic, err := NewRectangularPicture(v.TopLeft(), v.Width(), imageWidth, v.ImageHeight(imageWidth), divisions, divisions, maxIterations)
+	if err != nil {
+		return nil, err
+	}
+	pic.BigTopLeft = v.BigTopLeft()
+	pic.Rotation = v.Rotation
+	return pic, nil
+}
+
+// Area returns an area of the view with the given horizontal resolution, the vertical resolution is chosen by the
+// aspect of the view.
+func (v View) Area(horizontalResolution int, maxIterations int) *Area {
+	area := &Area{
+		HorizontalResolution: horizontalResolution,
+		VerticalResolution:   v.ImageHeight(horizontalResolution),
+		MaxIterations:        maxIterations,
+		TopLeft:              v.TopLeft(),
+		BottomRight:          v.Center - v.topLeftOffset(),
+		Rotation:             v.Rotation,
+	}
+	if v.BigCenter != nil {
+		bottomRight := v.BigCenter.Add(-v.topLeftOffset())
+		area.BigTopLeft = v.BigTopLeft()
+		area.BigBottomRight = &bottomRight
+	}
+	return area
+}
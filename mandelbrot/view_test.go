@@ -0,0 +1,62 @@
+package mandelbrot_test
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	. "github.com/metalblueberry/mandelbrot/mandelbrot"
+)
+
+func TestViewCorners(t *testing.T) {
+	tests := []struct {
+		view        View
+		topLeft     complex128
+		bottomRight complex128
+	}{
+		{View{Center: complex(-0.6, 0)}, complex(-2.1, 1.5), complex(0.9, -1.5)},
+		{View{Center: complex(-0.6, 0), Zoom: 2}, complex(-1.35, 0.75), complex(0.15, -0.75)},
+		{View{Center: complex(0, 1), Zoom: 1, Aspect: 2}, complex(-1.5, 1.75), complex(1.5, 0.25)},
+	}
+
+	for i, test := range tests {
+		area := test.view.Area(100, 10)
+		if cmplx.Abs(area.TopLeft-test.topLeft) > 1e-12 || cmplx.Abs(area.BottomRight-test.bottomRight) > 1e-12 {
+			t.Errorf("Test %d failed, expected corners %f and %f, got %f and %f", i, test.topLeft, test.bottomRight, area.TopLeft, area.BottomRight)
+		}
+	}
+}
+
+func TestViewPicture(t *testing.T) {
+	view := View{Center: complex(-0.6, 0), Zoom: 4, Aspect: 16.0 / 9}
+	pic, err := view.Picture(160, 5, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic.Init()
+	if pic.HorizontalResolution() != 160 || pic.VerticalResolution() != 90 {
+		t.Errorf("Expected a 160x90 picture, got %dx%d", pic.HorizontalResolution(), pic.VerticalResolution())
+	}
+	if pic.TopLeft != view.TopLeft() {
+		t.Errorf("Expected top left %f, got %f", view.TopLeft(), pic.TopLeft)
+	}
+	last := pic.GetArea(pic.HorizontalImageChunks*pic.VerticalImageChunks - 1)
+	if width := real(last.BottomRight - pic.TopLeft); math.Abs(width-view.Width()) > 1e-12 {
+		t.Errorf("Expected width %f, got %f", view.Width(), width)
+	}
+}
+
+func TestViewBigCenter(t *testing.T) {
+	center, err := ParseBigComplex("-1.7490863748149414", "-1e-25", 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view := View{Center: center.Complex128(), BigCenter: &center, Zoom: 1e24}
+	area := view.Area(10, 10)
+	if area.BigTopLeft == nil || area.BigBottomRight == nil {
+		t.Fatal("Expected high precision corners")
+	}
+	if span := area.BigBottomRight.Sub(*area.BigTopLeft); cmplx.Abs(span-complex(3e-24, -3e-24)) > 1e-36 {
+		t.Errorf("Expected span 3e-24, got %g", span)
+	}
+}